@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+var (
+	watchAllChecks bool
+	watchChecks    []string
+	watchExitOn    string
+)
+
+// --exit-on values for --all-checks / --check.
+const (
+	exitOnAll          = "all"           // wait for every check; fail if any failed
+	exitOnFirstFailure = "first-failure" // fail as soon as one check fails
+)
+
+// checkFilter selects check-runs by name. Patterns are globs ("e2e*"), or
+// regular expressions when wrapped in slashes ("/^Build (FE|EE)$/"). Every
+// pattern is required: the watch is not done until each one matches a run.
+type checkFilter struct {
+	patterns []string
+	matchers []func(string) bool
+}
+
+func newCheckFilter(patterns []string) (*checkFilter, error) {
+	f := &checkFilter{patterns: patterns}
+	for _, p := range patterns {
+		if len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid --check regex %q: %w", p, err)
+			}
+			f.matchers = append(f.matchers, re.MatchString)
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid --check glob %q: %w", p, err)
+		}
+		f.matchers = append(f.matchers, func(name string) bool {
+			ok, _ := path.Match(p, name)
+			return ok
+		})
+	}
+	return f, nil
+}
+
+func (f *checkFilter) match(name string) bool {
+	if len(f.matchers) == 0 {
+		return true
+	}
+	for _, m := range f.matchers {
+		if m(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// missing returns the patterns that match none of runs.
+func (f *checkFilter) missing(runs []github.CheckRun) []string {
+	var out []string
+	for i, m := range f.matchers {
+		found := false
+		for _, r := range runs {
+			if m(r.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, f.patterns[i])
+		}
+	}
+	return out
+}
+
+// checksState summarizes the watched checks of one commit.
+type checksState struct {
+	total, completed int
+	failed           []string
+	missing          []string // --check patterns without a run yet
+	suitesRunning    int      // check-suites in progress (runs may not exist yet)
+}
+
+func (s checksState) done() bool {
+	return s.total > 0 && s.completed == s.total && len(s.missing) == 0 && s.suitesRunning == 0
+}
+
+func evaluateChecks(runs []github.CheckRun, suites []github.CheckSuite, filter *checkFilter) checksState {
+	st := checksState{total: len(runs), missing: filter.missing(runs)}
+	for _, r := range runs {
+		if r.Status != "completed" {
+			continue
+		}
+		st.completed++
+		if !github.IsPassing(r.Conclusion) {
+			st.failed = append(st.failed, r.Name)
+		}
+	}
+	// Without a filter every check counts, so wait for suites whose runs have
+	// not been created yet. Queued suites are ignored: apps that never run
+	// leave their suite queued forever.
+	if len(filter.matchers) == 0 {
+		for _, cs := range suites {
+			if cs.Status == "in_progress" {
+				st.suitesRunning++
+			}
+		}
+	}
+	return st
+}
+
+func (s checksState) title(prNumber int, sha string) string {
+	t := fmt.Sprintf("Checks for PR #%d (%s): %d/%d completed", prNumber, github.ShortSHA(sha), s.completed, s.total)
+	if len(s.failed) > 0 {
+		t += fmt.Sprintf(", %d failed", len(s.failed))
+	}
+	if len(s.missing) > 0 {
+		t += fmt.Sprintf(", waiting for %s", strings.Join(s.missing, ", "))
+	}
+	return t
+}
+
+func boardRows(runs []github.CheckRun) []display.BoardRow {
+	rows := make([]display.BoardRow, 0, len(runs))
+	for _, r := range runs {
+		rows = append(rows, display.BoardRow{
+			Name:        r.Name,
+			Status:      r.Status,
+			Conclusion:  r.Conclusion,
+			StartedAt:   r.StartedAt,
+			CompletedAt: r.CompletedAt,
+		})
+	}
+	return rows
+}
+
+// watchAllChecksLoop polls every check-run on the PR head until the --exit-on
+// rule is met and returns the exit code (0 success, 1 failure, 2 error/timeout).
+func watchAllChecksLoop(ctx context.Context, target *watchTarget) int {
+	filter, err := newCheckFilter(watchChecks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if watchExitOn != exitOnAll && watchExitOn != exitOnFirstFailure {
+		fmt.Fprintf(os.Stderr, "Error: unknown --exit-on %q (want %q or %q)\n", watchExitOn, exitOnAll, exitOnFirstFailure)
+		return 2
+	}
+
+	board := display.NewBoard(cfg.NoColor, cfg.NoEmoji)
+
+	req := github.PollChecksRequest{Owner: target.owner, Repo: target.repo, SHA: target.sha}
+	headReq := github.PollHeadSHARequest{Owner: target.owner, Repo: target.repo, PRNumber: target.prNumber}
+
+	var (
+		runs   []github.CheckRun
+		suites []github.CheckSuite
+	)
+
+	const headCheckEvery = 3
+	pacer := newPollPacer(board.Log)
+	delay := basePollInterval
+
+	for polls := 0; ; polls++ {
+		if polls > 0 {
+			select {
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					board.Failure("Timeout waiting for checks to complete")
+				} else {
+					board.Stop()
+					fmt.Fprintf(os.Stderr, "\nInterrupted.\n")
+				}
+				return 2
+			case <-time.After(delay):
+			}
+		}
+
+		res, err := ghClient.PollChecks(ctx, req)
+		delay = pacer.update(err)
+		if err != nil {
+			if polls == 0 && !isRateLimited(err) {
+				board.Failure(fmt.Sprintf("Error: %v", err))
+				return 2
+			}
+			board.Log(fmt.Sprintf("Poll failed (%v), retrying...", err))
+			continue
+		}
+		req.ETag = res.ETag
+		if !res.NotModified {
+			runs = runs[:0]
+			for _, r := range res.Runs {
+				if filter.match(r.Name) {
+					runs = append(runs, r)
+				}
+			}
+			sort.Slice(runs, func(i, j int) bool { return runs[i].Name < runs[j].Name })
+			suites = res.Suites
+		}
+
+		st := evaluateChecks(runs, suites, filter)
+		board.Update(st.title(target.prNumber, target.sha), boardRows(runs))
+
+		if watchFollow && (st.done() || polls%headCheckEvery == headCheckEvery-1) {
+			if followHead(ctx, target, &headReq, "the checks", board.Log) {
+				req = github.PollChecksRequest{Owner: target.owner, Repo: target.repo, SHA: target.sha}
+				runs, suites = nil, nil
+				continue
+			}
+		}
+
+		switch {
+		case len(st.failed) > 0 && (watchExitOn == exitOnFirstFailure || st.done()):
+			board.Failure(fmt.Sprintf("Failed: %s", strings.Join(st.failed, ", ")))
+			return afterBuild(ctx, target, 1)
+		case st.done():
+			board.Success(fmt.Sprintf("All %d checks passed", st.total))
+			return afterBuild(ctx, target, 0)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+func TestCheckFilter(t *testing.T) {
+	f, err := newCheckFilter([]string{"e2e*", "/^Build (FE|EE)$/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"e2e: EKS":    true,
+		"Build FE":    true,
+		"Build EE":    true,
+		"Build CE":    false,
+		"Build FE v2": false,
+		"lint":        false,
+	} {
+		if got := f.match(name); got != want {
+			t.Errorf("match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if got := f.missing([]github.CheckRun{{Name: "Build EE"}}); !reflect.DeepEqual(got, []string{"e2e*"}) {
+		t.Errorf("missing = %v, want [e2e*]", got)
+	}
+
+	for _, bad := range []string{"[", "/(/"} {
+		if _, err := newCheckFilter([]string{bad}); err == nil {
+			t.Errorf("newCheckFilter(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestEvaluateChecks(t *testing.T) {
+	passed := github.CheckRun{Name: "Build FE", Status: "completed", Conclusion: "success"}
+	skipped := github.CheckRun{Name: "e2e: EKS", Status: "completed", Conclusion: "skipped"}
+	failed := github.CheckRun{Name: "lint", Status: "completed", Conclusion: "failure"}
+	running := github.CheckRun{Name: "e2e: AWS", Status: "in_progress"}
+	suiteRunning := github.CheckSuite{App: "github-actions", Status: "in_progress"}
+	suiteQueued := github.CheckSuite{App: "other-app", Status: "queued"}
+
+	tests := []struct {
+		name       string
+		runs       []github.CheckRun
+		suites     []github.CheckSuite
+		patterns   []string
+		wantDone   bool
+		wantFailed []string
+	}{
+		{name: "all passed", runs: []github.CheckRun{passed, skipped}, wantDone: true},
+		{name: "one failed", runs: []github.CheckRun{passed, failed}, wantDone: true, wantFailed: []string{"lint"}},
+		{name: "one running", runs: []github.CheckRun{passed, running}},
+		{name: "no runs yet"},
+		{name: "suite without runs yet", runs: []github.CheckRun{passed}, suites: []github.CheckSuite{suiteRunning}},
+		{name: "queued suite is ignored", runs: []github.CheckRun{passed}, suites: []github.CheckSuite{suiteQueued}, wantDone: true},
+		{name: "filter ignores suites", runs: []github.CheckRun{passed}, suites: []github.CheckSuite{suiteRunning}, patterns: []string{"Build*"}, wantDone: true},
+		{name: "pattern without a run", runs: []github.CheckRun{passed}, patterns: []string{"Build*", "e2e*"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newCheckFilter(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var runs []github.CheckRun
+			for _, r := range tt.runs {
+				if f.match(r.Name) {
+					runs = append(runs, r)
+				}
+			}
+
+			st := evaluateChecks(runs, tt.suites, f)
+			if st.done() != tt.wantDone {
+				t.Errorf("done = %v, want %v (%+v)", st.done(), tt.wantDone, st)
+			}
+			if !reflect.DeepEqual(st.failed, tt.wantFailed) {
+				t.Errorf("failed = %v, want %v", st.failed, tt.wantFailed)
+			}
+		})
+	}
+}
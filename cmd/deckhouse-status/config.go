@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/config"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/imagetag"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+)
+
+// configKeys are the flags that can also be set from the environment and
+// config files: root command flags by name, plus the subcommand flags of
+// subcommandConfigKeys.
+var configKeys = []string{
+	"short",
+	"no-github",
+	"no-registry",
+	"timeout",
+	"output",
+	"exit-code",
+	"tz",
+	"no-color",
+	"no-emoji",
+	"verbose",
+	"repo",
+	"kubeconfig",
+	"context",
+	"namespace",
+	"deployment",
+	"registry-secret",
+	"selector",
+	"controller-port",
+	"no-cache",
+	"cache-max-age",
+	"contexts",
+	"status-all-timeout",
+	"watch-build-timeout",
+	"rerun-build-timeout",
+}
+
+// subcommandConfigKeys map config keys to flags of subcommands. A key is
+// prefixed with its command when the flag name alone would be the root
+// flag's: "timeout" is the timeout of the status check only.
+var subcommandConfigKeys = map[string]struct {
+	cmd  *cobra.Command
+	flag string
+}{
+	"contexts":            {statusAllCmd, "contexts"},
+	"status-all-timeout":  {statusAllCmd, "timeout"},
+	"watch-build-timeout": {watchBuildCmd, "timeout"},
+	"rerun-build-timeout": {rerunBuildCmd, "timeout"},
+}
+
+var (
+	profile    string
+	cfgOrigins []config.Setting
+
+	// tagRules classify image tags; loadConfig replaces the defaults with the
+	// "tag-rules" of a config file.
+	tagRules       = imagetag.Default
+	tagRulesOrigin = config.Origin{Source: config.SourceDefault}
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration and where each value came from",
+	Long: `Prints every setting with its effective value and source.
+
+Precedence, highest first: command-line flag, environment variable
+(` + config.EnvPrefix + `<KEY>), user config (` + "$XDG_CONFIG_HOME" + `/deckhouse-status/config.yaml),
+system config (` + config.SystemPath + `), built-in default.
+A profile selected with --profile (or $` + config.ProfileEnv + `) overrides
+the top-level keys of the file it is defined in.
+
+Keys are the flag names of the status check. Flags of other commands are
+keyed as <command>-<flag>: status-all-timeout, watch-build-timeout and
+rerun-build-timeout; "timeout" applies to the status check only. The
+status-all --contexts flag keeps its name: "contexts".`,
+	Run: runConfigShow,
+}
+
+// loadConfig fills unset root flags from the environment and config files.
+// It runs before every command.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	if profile == "" {
+		profile = os.Getenv(config.ProfileEnv)
+	}
+
+	settings, err := config.Apply(configKeys, lookupConfigFlag, profile)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	cfgOrigins = settings
+
+	if err := loadTagRules(); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	userAgent := "deckhouse-status/" + version
+	ghClient = github.NewClient(github.Options{Token: os.Getenv("GITHUB_TOKEN"), UserAgent: userAgent})
+	regClient = registry.NewClient(registry.Options{UserAgent: userAgent})
+
+	if !noCache {
+		if dir := github.DefaultCacheDir(); dir != "" {
+			// A cache that cannot be created only costs API quota.
+			_ = ghClient.EnableCache(dir, cacheMaxAge)
+		}
+	}
+	return nil
+}
+
+// loadTagRules validates the tag rules of the config files, if any, so a bad
+// rule fails every command at startup.
+func loadTagRules() error {
+	var rules []imagetag.Rule
+	origin, ok, err := config.Section(config.TagRulesKey, profile, &rules)
+	if err != nil || !ok {
+		return err
+	}
+	compiled, err := imagetag.Compile(rules)
+	if err != nil {
+		return fmt.Errorf("%s: %w", origin.Path, err)
+	}
+	tagRules, tagRulesOrigin = compiled, origin
+	return nil
+}
+
+func lookupConfigFlag(key string) *pflag.Flag {
+	if sub, ok := subcommandConfigKeys[key]; ok {
+		return sub.cmd.Flags().Lookup(sub.flag)
+	}
+	for _, fs := range []*pflag.FlagSet{rootCmd.Flags(), rootCmd.PersistentFlags()} {
+		if f := fs.Lookup(key); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) {
+	fmt.Printf("User config:   %s\n", config.UserPath())
+	fmt.Printf("System config: %s\n", config.SystemPath)
+	if profile != "" {
+		fmt.Printf("Profile:       %s\n", profile)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range cfgOrigins {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Value, s.Origin)
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\n", config.TagRulesKey, strings.Join(tagRules.Names(), ","), tagRulesOrigin)
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/config"
+)
+
+func TestConfigSubcommandKeys(t *testing.T) {
+	// Only the user config below counts: no system config, no environment.
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Cleanup(config.SetSystemPath(filepath.Join(dir, "missing.yaml")))
+	for _, key := range configKeys {
+		t.Setenv(config.EnvName(key), "")
+		if err := os.Unsetenv(config.EnvName(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "deckhouse-status"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const file = "timeout: 20\ncontexts: [dev, stage]\nstatus-all-timeout: 60\nwatch-build-timeout: 7200\nrerun-build-timeout: 45\n"
+	if err := os.WriteFile(config.UserPath(), []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	savedCfg, savedContexts := cfg, statusAllContexts
+	savedTimeouts := []int{statusAllTimeout, watchTimeout, rerunTimeout}
+	t.Cleanup(func() {
+		cfg, statusAllContexts = savedCfg, savedContexts
+		statusAllTimeout, watchTimeout, rerunTimeout = savedTimeouts[0], savedTimeouts[1], savedTimeouts[2]
+	})
+
+	if _, err := config.Apply(configKeys, lookupConfigFlag, ""); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{"status": cfg.Timeout, "status-all": statusAllTimeout, "watch-build": watchTimeout, "rerun-build": rerunTimeout}
+	want := map[string]int{"status": 20, "status-all": 60, "watch-build": 7200, "rerun-build": 45}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s timeout = %d, want %d", name, got[name], w)
+		}
+	}
+	if len(statusAllContexts) != 2 || statusAllContexts[0] != "dev" || statusAllContexts[1] != "stage" {
+		t.Errorf("contexts = %v, want [dev stage]", statusAllContexts)
+	}
+}
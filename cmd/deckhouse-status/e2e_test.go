@@ -0,0 +1,769 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/fakeapi"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+	"github.com/glitchy-sheep/deckhouse-status/internal/verdict"
+)
+
+// These tests run the commands against fake GitHub and registry servers and a
+// fake clientset: a dev cluster running the FE build of PR #42.
+
+const (
+	testHost   = "dev-registry.example.com"
+	testRepo   = "sys/deckhouse-oss"
+	testTag    = "pr42"
+	buildCheck = "Build FE"
+)
+
+var (
+	oldCommit = fakeapi.Commit{SHA: strings.Repeat("a", 40), Author: "Jane Doe", Message: "First try", Date: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	newCommit = fakeapi.Commit{SHA: strings.Repeat("b", 40), Author: "Jane Doe", Message: "Address review", Date: time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)}
+	testPR    = fakeapi.PR{Owner: "deckhouse", Repo: "deckhouse", Number: 42, Title: "Fix the thing"}
+)
+
+type testEnv struct {
+	gh  *fakeapi.GitHub
+	reg *fakeapi.Registry
+	// running is the digest of the image the pod runs.
+	running    string
+	cs         *fake.Clientset
+	controller *fakeapi.Controller
+}
+
+// deckhousePod is a ready deckhouse replica with a kube-rbac-proxy sidecar
+// listed first, like the real Deployment.
+func deckhousePod(name, image, digest string, created time.Time) *corev1.Pod {
+	repo, _, _ := strings.Cut(image, ":")
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         kube.DefaultNamespace,
+			Labels:            map[string]string{"app": "deckhouse"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "master-0",
+			Containers: []corev1.Container{
+				{Name: "kube-rbac-proxy", Image: testHost + "/sys/kube-rbac-proxy:v0.18"},
+				{Name: "deckhouse", Image: image},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "deckhouse", ImageID: repo + "@" + digest, Ready: true},
+				{Name: "kube-rbac-proxy", ImageID: testHost + "/sys/kube-rbac-proxy@sha256:0000", Ready: true, RestartCount: 2},
+			},
+		},
+	}
+}
+
+// newTestEnv deploys the image of commit and points the package's clients at
+// the fakes. PR #42 exists with commit as its head; tests add check-runs.
+func newTestEnv(t *testing.T, commit fakeapi.Commit, token string) *testEnv {
+	t.Helper()
+	env := newTestEnvTag(t, testTag, commit, token)
+	env.gh.AddPR(testPR, commit)
+	return env
+}
+
+// newTestEnvTag deploys the image of commit pushed as tag; GitHub is empty.
+func newTestEnvTag(t *testing.T, tag string, commit fakeapi.Commit, token string) *testEnv {
+	t.Helper()
+	env := &testEnv{gh: fakeapi.NewGitHub(t), reg: fakeapi.NewRegistry(t)}
+	env.reg.Auth = fakeapi.BasicAuth("dev", "secret")
+	env.running = env.reg.Push(testRepo, tag, fakeapi.Image{Revision: commit.SHA, Created: commit.Date.Add(30 * time.Minute), Index: true})
+
+	env.cs = fake.NewSimpleClientset(
+		deckhousePod("deckhouse-7d9f8-abcde", testHost+"/"+testRepo+":"+tag, env.running, commit.Date.Add(time.Hour)),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: kube.DefaultSecret, Namespace: kube.DefaultNamespace},
+			Data:       map[string][]byte{".dockerconfigjson": dockerConfig(t, env.reg.Auth)},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        kube.DefaultDeployment,
+				Namespace:   kube.DefaultNamespace,
+				Annotations: map[string]string{kube.RepoAnnotation: "deckhouse/deckhouse"},
+			},
+		},
+	)
+
+	savedGH, savedReg, savedKube, savedCfg, savedInterval := ghClient, regClient, newKubeClient, cfg, basePollInterval
+	savedOpts, savedRolloutInterval := kubeOpts, rolloutPollInterval
+	savedFollow, savedRerun, savedRestart, savedWaitRollout := watchFollow, watchRerunOnFailure, watchRestart, watchWaitRollout
+	savedAllChecks, savedChecks, savedExitOn := watchAllChecks, watchChecks, watchExitOn
+	t.Cleanup(func() {
+		ghClient, regClient, newKubeClient, cfg, basePollInterval = savedGH, savedReg, savedKube, savedCfg, savedInterval
+		kubeOpts, rolloutPollInterval = savedOpts, savedRolloutInterval
+		watchFollow, watchRerunOnFailure, watchRestart, watchWaitRollout = savedFollow, savedRerun, savedRestart, savedWaitRollout
+		watchAllChecks, watchChecks, watchExitOn = savedAllChecks, savedChecks, savedExitOn
+	})
+
+	ghClient = github.NewClient(github.Options{BaseURL: env.gh.URL, Token: token})
+	regClient = registry.NewClient(registry.Options{BaseURL: env.reg.URL})
+	env.controller = fakeapi.NewController(env.cs)
+	newKubeClient = func(opts kube.Options) (*kube.Client, error) {
+		return kube.NewClientFromClientset(env.cs, opts), nil
+	}
+	cfg = display.Config{NoColor: true, NoEmoji: true}
+	basePollInterval = 10 * time.Millisecond
+	rolloutPollInterval = 10 * time.Millisecond
+	watchFollow, watchRerunOnFailure, watchRestart, watchWaitRollout = false, 0, false, false
+	watchAllChecks, watchChecks, watchExitOn = false, nil, exitOnAll
+	return env
+}
+
+func dockerConfig(t *testing.T, auth string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"auths": map[string]any{testHost: map[string]string{"auth": auth}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		setup func(env *testEnv)
+
+		wantKind   verdict.Kind
+		wantSource verdict.Source
+		wantExit   int
+		wantAhead  int
+	}{
+		{
+			name: "up to date",
+			setup: func(env *testEnv) {
+				env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: oldCommit.Date.Add(30 * time.Minute)})
+			},
+			wantKind: verdict.UpToDate, wantSource: verdict.SourceRegistry, wantExit: exitUpToDate,
+		},
+		{
+			name:  "up to date over GraphQL",
+			token: "secret",
+			setup: func(env *testEnv) {
+				env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: oldCommit.Date.Add(30 * time.Minute)})
+			},
+			wantKind: verdict.UpToDate, wantSource: verdict.SourceRegistry, wantExit: exitUpToDate,
+		},
+		{
+			name: "registry has a newer image",
+			setup: func(env *testEnv) {
+				env.gh.AddPR(testPR, newCommit)
+				env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: newCommit.Date.Add(30 * time.Minute)})
+				env.reg.Push(testRepo, testTag, fakeapi.Image{Revision: newCommit.SHA, Created: newCommit.Date.Add(30 * time.Minute), Index: true})
+			},
+			wantKind: verdict.Outdated, wantSource: verdict.SourceRegistry, wantExit: exitOutdated, wantAhead: 1,
+		},
+		{
+			name: "new commit building",
+			setup: func(env *testEnv) {
+				env.gh.AddPR(testPR, newCommit)
+				env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "in_progress"})
+			},
+			wantKind: verdict.Building, wantSource: verdict.SourceBuild, wantExit: exitBuilding, wantAhead: 1,
+		},
+		{
+			name: "tag gone, deployed commit behind head",
+			setup: func(env *testEnv) {
+				env.gh.AddPR(testPR, newCommit)
+				env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: newCommit.Date.Add(30 * time.Minute)})
+				env.reg.DeleteTag(testRepo, testTag)
+			},
+			wantKind: verdict.Outdated, wantSource: verdict.SourceCommit, wantExit: exitOutdated, wantAhead: 1,
+		},
+		{
+			name: "tag gone, build of head failed",
+			setup: func(env *testEnv) {
+				env.gh.AddPR(testPR, newCommit)
+				env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "failure", CompletedAt: newCommit.Date.Add(30 * time.Minute)})
+				env.reg.DeleteTag(testRepo, testTag)
+			},
+			wantKind: verdict.BuildFailed, wantSource: verdict.SourceBuild, wantExit: exitBuildFailed, wantAhead: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, oldCommit, tt.token)
+			tt.setup(env)
+
+			client, err := newKubeClient(kubeOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fetchPR := func(ctx context.Context, owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error) {
+				return ghClient.FetchPRInfo(ctx, owner, repo, prNumber, checkNames, false)
+			}
+			data, err := collectStatus(context.Background(), client, fetchPR, statusOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.GitHubErr != nil || data.Registry.Err != nil || data.CompareErr != nil {
+				t.Fatalf("errors: GitHub %v, registry %v, compare %v", data.GitHubErr, data.Registry.Err, data.CompareErr)
+			}
+
+			if data.DeployedSHA != oldCommit.SHA {
+				t.Errorf("deployed commit = %s, want %s from the image labels", data.DeployedSHA, oldCommit.SHA)
+			}
+			ahead := 0
+			if data.Compare != nil {
+				ahead = data.Compare.AheadBy
+			}
+			if ahead != tt.wantAhead {
+				t.Errorf("deployed commit is %d behind PR head, want %d", ahead, tt.wantAhead)
+			}
+
+			v := verdict.Evaluate(data.VerdictInput())
+			if v.Kind != tt.wantKind || v.Source != tt.wantSource {
+				t.Errorf("verdict = %s from %s (%s), want %s from %s", v.Kind, v.Source, v.Reason, tt.wantKind, tt.wantSource)
+			}
+			if code := statusExitCode(data); code != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", code, tt.wantExit)
+			}
+		})
+	}
+}
+
+func TestStatusMixedRollout(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: oldCommit.Date.Add(30 * time.Minute)})
+	// A newer replica runs another image; the primary pod is still current.
+	newer := env.reg.Push(testRepo, "pr42-next", fakeapi.Image{Revision: newCommit.SHA, Created: newCommit.Date})
+	pod := deckhousePod("deckhouse-8e0a9-fghij", testHost+"/"+testRepo+":"+testTag, newer, newCommit.Date.Add(time.Hour))
+	if _, err := env.cs.CoreV1().Pods(kube.DefaultNamespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	data := collectTestStatus(t)
+	if n := len(data.Cluster.Pods); n != 2 {
+		t.Fatalf("got %d pods, want 2", n)
+	}
+	if data.Cluster.PodName != "deckhouse-7d9f8-abcde" || data.Cluster.RunningDigest != env.running {
+		t.Errorf("primary = %s running %s, want the first pod running %s", data.Cluster.PodName, data.Cluster.RunningDigest, env.running)
+	}
+	main := data.Cluster.Pods[0].Main()
+	if main == nil || main.Name != "deckhouse" || len(data.Cluster.Pods[0].Containers) != 2 {
+		t.Fatalf("containers = %+v, want the deckhouse container as main", data.Cluster.Pods[0].Containers)
+	}
+	if sidecar := data.Cluster.Pods[0].Containers[0]; sidecar.Restarts != 2 || !sidecar.Ready {
+		t.Errorf("sidecar = %+v, want ready with 2 restarts", sidecar)
+	}
+
+	v := verdict.Evaluate(data.VerdictInput())
+	if v.Kind != verdict.MixedRollout || !strings.Contains(v.Reason, "1 of 2 replicas") {
+		t.Errorf("verdict = %s (%s), want mixed rollout of 1 of 2 replicas", v.Kind, v.Reason)
+	}
+	if code := statusExitCode(data); code != exitMixed {
+		t.Errorf("exit code = %d, want %d", code, exitMixed)
+	}
+}
+
+func TestStatusCrashLoop(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: oldCommit.Date.Add(30 * time.Minute)})
+
+	ctx := context.Background()
+	pods := env.cs.CoreV1().Pods(kube.DefaultNamespace)
+	pod, err := pods.Get(ctx, "deckhouse-7d9f8-abcde", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod.Status.Conditions[0].Status = corev1.ConditionFalse
+	st := &pod.Status.ContainerStatuses[0]
+	st.Ready, st.RestartCount = false, 12
+	st.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s restarting failed container"}}
+	st.LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1, FinishedAt: metav1.Now()}}
+	if _, err := pods.Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for i, ev := range []corev1.Event{
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod.Name}, Type: corev1.EventTypeWarning, Reason: "BackOff", Message: "Back-off restarting failed container", Count: 40, LastTimestamp: metav1.Now()},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod.Name}, Type: corev1.EventTypeNormal, Reason: "Pulled", Count: 1},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "other"}, Type: corev1.EventTypeWarning, Reason: "FailedMount", Count: 1},
+	} {
+		ev.Name, ev.Namespace = fmt.Sprintf("event-%d", i), kube.DefaultNamespace
+		if _, err := env.cs.CoreV1().Events(kube.DefaultNamespace).Create(ctx, &ev, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := collectTestStatus(t)
+	if len(data.Cluster.Events) != 1 || data.Cluster.Events[0].Reason != "BackOff" || data.Cluster.Events[0].Count != 40 {
+		t.Errorf("events = %+v, want the BackOff warning of the pod only", data.Cluster.Events)
+	}
+	main := data.Cluster.Primary().Main()
+	if main.Waiting != "CrashLoopBackOff" || main.LastTermination == nil || main.LastTermination.ExitCode != 1 {
+		t.Errorf("deckhouse container = %+v, want CrashLoopBackOff after exit code 1", main)
+	}
+
+	v := verdict.Evaluate(data.VerdictInput())
+	if v.Kind != verdict.UpToDate || v.Health != "CrashLoopBackOff" || !strings.Contains(v.HealthReason, "12 restarts") {
+		t.Errorf("verdict = %s, health %q (%s), want up to date but CrashLoopBackOff with 12 restarts", v.Kind, v.Health, v.HealthReason)
+	}
+	if v.Action != verdict.ActionCheckPod {
+		t.Errorf("action = %q, want %q", v.Action, verdict.ActionCheckPod)
+	}
+	if code := statusExitCode(data); code != exitUnhealthy {
+		t.Errorf("exit code = %d, want %d", code, exitUnhealthy)
+	}
+}
+
+func TestStatusController(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: oldCommit.Date.Add(30 * time.Minute)})
+	env.controller.SetConverging(true)
+	env.controller.SetQueue("main", 12)
+	env.controller.SetQueue("main-subqueue-kubernetes-Synchronization", 3)
+	env.controller.SetModuleErrors("prometheus", 1)
+	env.controller.SetModuleErrors("cni-cilium", 3)
+	env.controller.SetModuleErrors("ingress-nginx", 0)
+
+	data := collectTestStatus(t)
+	if data.ControllerErr != nil {
+		t.Fatal(data.ControllerErr)
+	}
+	want := &kube.ControllerStatus{
+		Pod:          "deckhouse-7d9f8-abcde",
+		MainQueue:    12,
+		Converge:     kube.ConvergeStartup,
+		ModuleErrors: []kube.ModuleErrors{{Module: "cni-cilium", Errors: 3}, {Module: "prometheus", Errors: 1}},
+	}
+	if !reflect.DeepEqual(data.Controller, want) {
+		t.Errorf("controller = %+v, want %+v", data.Controller, want)
+	}
+
+	env.controller.SetConverging(false)
+	if data = collectTestStatus(t); data.Controller.Converge != kube.ConvergeInProgress {
+		t.Errorf("converge = %q with a busy main queue, want %q", data.Controller.Converge, kube.ConvergeInProgress)
+	}
+	env.controller.SetQueue("main", 0)
+	if data = collectTestStatus(t); data.Controller.Converge != kube.ConvergeDone {
+		t.Errorf("converge = %q, want %q", data.Controller.Converge, kube.ConvergeDone)
+	}
+
+	// An unreachable controller does not fail the status.
+	kubeOpts.ControllerPort = "9650"
+	data = collectTestStatus(t)
+	if data.Controller != nil || data.ControllerErr == nil || !strings.Contains(data.ControllerErr.Error(), "connection refused") {
+		t.Errorf("controller = %+v, err %v, want a connection error", data.Controller, data.ControllerErr)
+	}
+	if v := verdict.Evaluate(data.VerdictInput()); v.Kind != verdict.UpToDate {
+		t.Errorf("verdict = %s, want up to date", v.Kind)
+	}
+
+	kubeOpts.ControllerPort = ""
+
+	// Only a server error of /readyz means the startup converge; a denied or
+	// missing endpoint is an error.
+	env.controller.SetFailure("/readyz", http.StatusServiceUnavailable)
+	if data = collectTestStatus(t); data.ControllerErr != nil || data.Controller.Converge != kube.ConvergeStartup {
+		t.Errorf("controller = %+v, err %v, want startup on 503", data.Controller, data.ControllerErr)
+	}
+	for _, code := range []int{http.StatusForbidden, http.StatusNotFound} {
+		env.controller.SetFailure("/readyz", code)
+		if data = collectTestStatus(t); data.Controller != nil || data.ControllerErr == nil || !strings.Contains(data.ControllerErr.Error(), "readiness") {
+			t.Errorf("controller = %+v, err %v, want a readiness error on %d", data.Controller, data.ControllerErr, code)
+		}
+	}
+	env.controller.SetFailure("/readyz", 0)
+
+	// Neither the short output nor the status-all table shows the controller.
+	requests := env.controller.Requests()
+	fetchPR, leave := newPRBatcher(context.Background(), 1).forCluster()
+	row := collectCluster(context.Background(), "dev", fetchPR)
+	leave()
+	if row.Err != nil || row.Data.Controller != nil || env.controller.Requests() != requests {
+		t.Errorf("controller fetched for status-all (err %v)", row.Err)
+	}
+	cfg.Short = true
+	if data = collectTestStatus(t); data.Controller != nil || env.controller.Requests() != requests {
+		t.Errorf("controller fetched for the short output")
+	}
+}
+
+func TestWaitConverge(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	env.controller.SetConverging(true)
+	env.controller.SetQueue("main", 5)
+	client, err := newKubeClient(kubeOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spinner := display.NewSpinner(true, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if code := waitConverge(ctx, client, "deckhouse-7d9f8-abcde", spinner); code != 2 {
+		t.Errorf("exit code = %d while converging, want 2 on timeout", code)
+	}
+
+	// Startup converge ends first, then the main queue drains.
+	base := env.controller.Requests()
+	go func() {
+		for env.controller.Requests() < base+4 {
+			time.Sleep(time.Millisecond)
+		}
+		env.controller.SetConverging(false)
+		for env.controller.Requests() < base+8 {
+			time.Sleep(time.Millisecond)
+		}
+		env.controller.SetQueue("main", 0)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if code := waitConverge(ctx, client, "deckhouse-7d9f8-abcde", spinner); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+}
+
+func TestStatusBranch(t *testing.T) {
+	env := newTestEnvTag(t, "main", oldCommit, "")
+	env.gh.AddCommit(oldCommit)
+	env.gh.SetBranch("deckhouse", "deckhouse", "main", newCommit)
+	env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: newCommit.Date.Add(30 * time.Minute)})
+	env.reg.DeleteTag(testRepo, "main")
+
+	data := collectTestStatus(t)
+	if data.Branch == nil || data.Branch.HeadSHA != newCommit.SHA || data.Branch.BuildConclusion != "success" {
+		t.Fatalf("branch = %+v, want main at %s built", data.Branch, newCommit.SHA)
+	}
+	if data.Compare == nil || data.Compare.AheadBy != 1 {
+		t.Errorf("compare = %+v, want 1 commit behind", data.Compare)
+	}
+	v := verdict.Evaluate(data.VerdictInput())
+	if v.Kind != verdict.Outdated || !strings.Contains(v.Reason, "behind main") {
+		t.Errorf("verdict = %s (%s), want outdated behind main", v.Kind, v.Reason)
+	}
+}
+
+func TestStatusRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases []string
+
+		wantKind   verdict.Kind
+		wantSource verdict.Source
+		wantExit   int
+	}{
+		{name: "latest patch", releases: []string{"v1.65.9", "v1.66.3"}, wantKind: verdict.UpToDate, wantSource: verdict.SourceRegistry, wantExit: exitUpToDate},
+		{name: "newer patch", releases: []string{"v1.66.3", "v1.66.5", "v1.67.0"}, wantKind: verdict.Outdated, wantSource: verdict.SourceRelease, wantExit: exitOutdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnvTag(t, "v1.66.3", oldCommit, "")
+			for i, tag := range tt.releases {
+				env.gh.AddRelease("deckhouse", "deckhouse", fakeapi.Release{Tag: tag, PublishedAt: oldCommit.Date.AddDate(0, 0, i)})
+			}
+			env.gh.AddRelease("deckhouse", "deckhouse", fakeapi.Release{Tag: "v1.66.9", Prerelease: true})
+
+			data := collectTestStatus(t)
+			if data.Release == nil || data.Release.URL == "" {
+				t.Fatalf("release = %+v, want v1.66.3 found", data.Release)
+			}
+			v := verdict.Evaluate(data.VerdictInput())
+			if v.Kind != tt.wantKind || v.Source != tt.wantSource {
+				t.Errorf("verdict = %s from %s (%s), want %s from %s", v.Kind, v.Source, v.Reason, tt.wantKind, tt.wantSource)
+			}
+			if code := statusExitCode(data); code != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", code, tt.wantExit)
+			}
+		})
+	}
+}
+
+// collectTestStatus runs collectStatus against the test env and fails on
+// any lookup error.
+func collectTestStatus(t *testing.T) display.RenderData {
+	t.Helper()
+	client, err := newKubeClient(kubeOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetchPR := func(ctx context.Context, owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error) {
+		return ghClient.FetchPRInfo(ctx, owner, repo, prNumber, checkNames, false)
+	}
+	data, err := collectStatus(context.Background(), client, fetchPR, statusOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.GitHubErr != nil || data.CompareErr != nil {
+		t.Fatalf("errors: GitHub %v, compare %v", data.GitHubErr, data.CompareErr)
+	}
+	return data
+}
+
+func TestStatusKubernetesError(t *testing.T) {
+	newTestEnv(t, oldCommit, "")
+	client := kube.NewClientFromClientset(fake.NewSimpleClientset(), kubeOpts)
+
+	_, err := collectStatus(context.Background(), client, nil, statusOptions())
+	if err == nil || !strings.Contains(err.Error(), "no pods") {
+		t.Errorf("err = %v, want no pods found", err)
+	}
+}
+
+func TestStatusAllSkipsCompare(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	env.gh.AddPR(testPR, newCommit)
+	env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "success", CompletedAt: newCommit.Date.Add(30 * time.Minute)})
+	env.reg.DeleteTag(testRepo, testTag)
+
+	// The status-all table does not show the reason, so it skips the comparison.
+	fetchPR := func(ctx context.Context, owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error) {
+		return ghClient.FetchPRInfo(ctx, owner, repo, prNumber, checkNames, true)
+	}
+	row := collectCluster(context.Background(), "dev", fetchPR)
+	if row.Err != nil || row.Data.Compare != nil {
+		t.Fatalf("compare fetched for status-all (err %v)", row.Err)
+	}
+	for _, r := range env.gh.Requests() {
+		if strings.Contains(r.URL.Path, "/compare/") {
+			t.Errorf("status-all requested %s", r.URL.Path)
+		}
+	}
+	if v := verdict.Evaluate(row.Data.VerdictInput()); v.Kind != verdict.Outdated {
+		t.Errorf("status-all verdict = %s (%s), want outdated", v.Kind, v.Reason)
+	}
+}
+
+// watchInBackground runs watch-build for the cluster and returns its exit code
+// channel.
+func watchInBackground(t *testing.T) <-chan int {
+	return loopInBackground(t, watchBuild)
+}
+
+// loopInBackground runs a watch loop for the cluster and returns its exit code
+// channel.
+func loopInBackground(t *testing.T, loop func(context.Context, *watchTarget) int) <-chan int {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	target, err := resolveWatchTarget(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan int, 1)
+	go func() { done <- loop(ctx, target) }()
+	return done
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func checkRunPolls(gh *fakeapi.GitHub) int {
+	n := 0
+	for _, r := range gh.Requests() {
+		if strings.HasSuffix(r.URL.Path, "/check-runs") {
+			n++
+		}
+	}
+	return n
+}
+
+// captureStderr redirects os.Stderr to a pipe. The returned function restores
+// it and returns what was written.
+func captureStderr(t *testing.T) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stderr
+	os.Stderr = w
+	out := make(chan string, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	var once sync.Once
+	var written string
+	stop := func() string {
+		once.Do(func() {
+			os.Stderr = saved
+			_ = w.Close()
+			written = <-out
+			_ = r.Close()
+		})
+		return written
+	}
+	t.Cleanup(func() { stop() })
+	return stop
+}
+
+func exitCode(t *testing.T, done <-chan int) int {
+	t.Helper()
+	select {
+	case code := <-done:
+		return code
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch-build did not finish")
+		return -1
+	}
+}
+
+func TestWatchBuild(t *testing.T) {
+	for _, conclusion := range []string{"success", "failure"} {
+		t.Run(conclusion, func(t *testing.T) {
+			env := newTestEnv(t, oldCommit, "")
+			env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "queued"})
+
+			done := watchInBackground(t)
+			waitFor(t, "first poll", func() bool { return checkRunPolls(env.gh) >= 1 })
+			env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "in_progress"})
+			waitFor(t, "poll of the running build", func() bool { return checkRunPolls(env.gh) >= 3 })
+			env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: conclusion, RunID: 10})
+
+			want := 0
+			if conclusion != "success" {
+				want = 1
+			}
+			if code := exitCode(t, done); code != want {
+				t.Errorf("exit code = %d, want %d", code, want)
+			}
+		})
+	}
+}
+
+func TestWatchAllChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		noEmoji    bool
+		conclusion string // of the e2e check
+
+		wantExit int
+		wantLine string
+	}{
+		{name: "passed", conclusion: "success", wantExit: 0, wantLine: "✅ All 2 checks passed"},
+		{name: "passed without emoji", noEmoji: true, conclusion: "success", wantExit: 0, wantLine: "+ All 2 checks passed"},
+		{name: "failed without emoji", noEmoji: true, conclusion: "failure", wantExit: 1, wantLine: "x Failed: e2e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, oldCommit, "")
+			watchAllChecks = true
+			cfg.NoEmoji = tt.noEmoji
+			env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success"})
+			env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 2, Name: "e2e", Status: "in_progress"})
+
+			stderr := captureStderr(t)
+			done := loopInBackground(t, watchAllChecksLoop)
+			waitFor(t, "first poll", func() bool { return checkRunPolls(env.gh) >= 1 })
+			env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 2, Name: "e2e", Status: "completed", Conclusion: tt.conclusion})
+
+			code := exitCode(t, done)
+			out := stderr()
+			if code != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", code, tt.wantExit)
+			}
+			if !strings.Contains(out, tt.wantLine) {
+				t.Errorf("output has no %q:\n%s", tt.wantLine, out)
+			}
+			if tt.noEmoji && strings.ContainsAny(out, "✅❌⏳") {
+				t.Errorf("emoji with --no-emoji:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestWatchBuildRestartFailed(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	watchRestart, watchWaitRollout = true, true
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "completed", Conclusion: "success"})
+	env.cs.PrependReactor("update", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("deployments.apps is forbidden")
+	})
+
+	// The exit code reports the build; the restart failure is only printed.
+	if code := exitCode(t, watchInBackground(t)); code != 0 {
+		t.Errorf("exit code = %d, want 0 for the passed build", code)
+	}
+}
+
+func TestWatchBuildRerunOnFailure(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "secret")
+	watchRerunOnFailure = 1
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{
+		ID: 1, Name: buildCheck, Status: "completed", Conclusion: "failure", RunID: 10,
+		FailedSteps: []string{"Build image"}, Annotations: []string{"Process completed with exit code 1."},
+	})
+
+	done := watchInBackground(t)
+	waitFor(t, "re-run", func() bool { return len(env.gh.Reruns()) == 1 })
+	// Until GitHub creates the new check-run the failed one is still the latest.
+	polls := checkRunPolls(env.gh)
+	waitFor(t, "poll after the re-run", func() bool { return checkRunPolls(env.gh) > polls })
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "success", RunID: 10})
+
+	if code := exitCode(t, done); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if got := env.gh.Reruns(); len(got) != 1 || got[0] != 10 {
+		t.Errorf("re-runs = %v, want [10]", got)
+	}
+}
+
+func TestWatchBuildFollow(t *testing.T) {
+	env := newTestEnv(t, oldCommit, "")
+	watchFollow = true
+	env.gh.SetCheckRun(oldCommit.SHA, fakeapi.CheckRun{ID: 1, Name: buildCheck, Status: "in_progress"})
+
+	done := watchInBackground(t)
+	waitFor(t, "first poll", func() bool { return checkRunPolls(env.gh) >= 1 })
+	// A push replaces the head; the old build would never report the new commit.
+	env.gh.AddPR(testPR, newCommit)
+	env.gh.SetCheckRun(newCommit.SHA, fakeapi.CheckRun{ID: 2, Name: buildCheck, Status: "completed", Conclusion: "success"})
+
+	if code := exitCode(t, done); code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	polled := false
+	for _, r := range env.gh.Requests() {
+		if strings.Contains(r.URL.Path, newCommit.SHA+"/check-runs") {
+			polled = true
+		}
+	}
+	if !polled {
+		t.Error("the new head's build was never polled")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/verdict"
+)
+
+// Exit codes of the root command with --exit-code. 0/1/2 mean the same as in
+// watch-build: success, negative result, error.
+const (
+	exitUpToDate    = 0
+	exitOutdated    = 1
+	exitError       = 2 // Kubernetes, GitHub or registry could not be queried
+	exitBuilding    = 3 // build running or not started yet
+	exitBuildFailed = 4
+	exitUnknown     = 5 // data is available but does not allow a decision
+	exitMixed       = 6 // replicas run different images
+	exitUnhealthy   = 7 // up to date, but the pod crashloops or cannot start
+)
+
+const exitCodeHelp = `Exit codes with --exit-code:
+  0  up to date
+  1  outdated
+  2  error querying Kubernetes, GitHub or the registry
+  3  build in progress or waiting for CI
+  4  build failed
+  5  cannot determine
+  6  replicas run different images (rollout in progress)
+  7  up to date, but the pod is unhealthy (crashlooping, image pull errors)`
+
+// statusExitCode maps the verdict for d to an exit code.
+func statusExitCode(d display.RenderData) int {
+	v := verdict.Evaluate(d.VerdictInput())
+
+	switch v.Kind {
+	case verdict.UpToDate:
+		if v.Health != "" {
+			return exitUnhealthy
+		}
+		return exitUpToDate
+	case verdict.Outdated:
+		return exitOutdated
+	case verdict.Building, verdict.WaitingForCI:
+		return exitBuilding
+	case verdict.BuildFailed:
+		return exitBuildFailed
+	case verdict.MixedRollout:
+		return exitMixed
+	}
+
+	if d.GitHubErr != nil || (d.Registry != nil && d.Registry.Err != nil) {
+		return exitError
+	}
+	return exitUnknown
+}
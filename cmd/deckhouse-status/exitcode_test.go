@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+)
+
+func TestStatusExitCode(t *testing.T) {
+	cluster := &kube.ClusterInfo{PodName: "deckhouse-1", PodCreated: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}
+	replica := func(name, digest string) kube.PodInfo {
+		return kube.PodInfo{Name: name, Phase: "Running", Containers: []kube.ContainerInfo{{Name: "deckhouse", Main: true, Digest: digest}}}
+	}
+	mixed := &kube.ClusterInfo{PodName: "deckhouse-1", Pods: []kube.PodInfo{replica("deckhouse-0", "sha256:old"), replica("deckhouse-1", "sha256:new")}}
+	crashing := &kube.ClusterInfo{PodName: "deckhouse-1", Pods: []kube.PodInfo{{
+		Name: "deckhouse-1", Phase: "Running", Containers: []kube.ContainerInfo{{Name: "deckhouse", Main: true, Digest: "sha256:a", Waiting: "CrashLoopBackOff"}},
+	}}}
+	build := func(status, conclusion string) *github.PRInfo {
+		return &github.PRInfo{HeadBuild: github.HeadBuild{BuildCheckName: "Build FE", BuildStatus: status, BuildConclusion: conclusion, BuildCompletedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}}
+	}
+
+	tests := []struct {
+		name string
+		data display.RenderData
+		want int
+	}{
+		{name: "up to date", data: display.RenderData{Registry: &registry.Result{TagExists: true, Digest: "sha256:a", DigestMatch: true}}, want: exitUpToDate},
+		{name: "outdated", data: display.RenderData{Registry: &registry.Result{TagExists: true, Digest: "sha256:a"}}, want: exitOutdated},
+		{name: "building", data: display.RenderData{PR: build("in_progress", "")}, want: exitBuilding},
+		{name: "waiting for CI", data: display.RenderData{PR: build("", "")}, want: exitBuilding},
+		{name: "build failed", data: display.RenderData{PR: build("completed", "failure")}, want: exitBuildFailed},
+		{name: "registry error", data: display.RenderData{Registry: &registry.Result{Err: errors.New("401 Unauthorized")}}, want: exitError},
+		{name: "GitHub error", data: display.RenderData{GitHubErr: errors.New("rate limited")}, want: exitError},
+		{name: "up to date but crashlooping", data: display.RenderData{Cluster: crashing, Registry: &registry.Result{TagExists: true, Digest: "sha256:a", DigestMatch: true}}, want: exitUnhealthy},
+		{name: "outdated and crashlooping", data: display.RenderData{Cluster: crashing, Registry: &registry.Result{TagExists: true, Digest: "sha256:b"}}, want: exitOutdated},
+		{name: "mixed rollout", data: display.RenderData{Cluster: mixed, Registry: &registry.Result{TagExists: true, Digest: "sha256:new", DigestMatch: true}}, want: exitMixed},
+		{name: "cannot determine", data: display.RenderData{PR: build("completed", "cancelled")}, want: exitUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.data.Cluster == nil {
+				tt.data.Cluster = cluster
+			}
+			if got := statusExitCode(tt.data); got != tt.want {
+				t.Errorf("statusExitCode = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,17 +2,33 @@ package main
 
 import (
 	"os"
+	"time"
 
 	cc "github.com/ivanpirog/coloredcobra"
 	"github.com/spf13/cobra"
 
+	"github.com/glitchy-sheep/deckhouse-status/internal/config"
 	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
 	"github.com/glitchy-sheep/deckhouse-status/internal/motd"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
 )
 
 var (
-	version = "dev"
-	cfg     display.Config
+	version  = "dev"
+	cfg      display.Config
+	kubeOpts kube.Options
+
+	statusExitCodes bool
+	noCache         bool
+	cacheMaxAge     time.Duration
+
+	// API clients, created by loadConfig.
+	ghClient  *github.Client
+	regClient *registry.Client
+	// newKubeClient connects to a cluster; tests swap in a fake clientset.
+	newKubeClient = kube.NewClient
 )
 
 var rootCmd = &cobra.Command{
@@ -22,7 +38,9 @@ var rootCmd = &cobra.Command{
 	Long: `Checks the running Deckhouse pod, compares it with the latest CI build,
 and shows whether your PR deployment is up to date.
 
-Data sources: Kubernetes API, GitHub public API, Docker Registry v2.`,
+Data sources: Kubernetes API, GitHub public API, Docker Registry v2.
+
+` + exitCodeHelp,
 	Run:          runStatus,
 	SilenceUsage: true,
 }
@@ -53,24 +71,60 @@ var editMotdCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.PersistentPreRunE = loadConfig
+
 	rootCmd.Flags().BoolVarP(&cfg.Short, "short", "s", false, "Compact output (3 lines)")
 	rootCmd.Flags().BoolVar(&cfg.NoGitHub, "no-github", false, "Skip GitHub API calls")
 	rootCmd.Flags().BoolVar(&cfg.NoRegistry, "no-registry", false, "Skip registry checks")
 	rootCmd.Flags().IntVar(&cfg.Timeout, "timeout", 15, "Timeout in seconds")
+	rootCmd.Flags().StringVarP(&cfg.Output, "output", "o", display.OutputText, "Output format: text or json")
+	rootCmd.Flags().BoolVar(&statusExitCodes, "exit-code", false, "Exit with a code describing the verdict (see help)")
 
 	// Persistent flags available to all subcommands
 	rootCmd.PersistentFlags().StringVar(&cfg.TZ, "tz", "Europe/Moscow", "Timezone: IANA name or numeric offset (+3, -5)")
 	rootCmd.PersistentFlags().BoolVar(&cfg.NoColor, "no-color", false, "Disable colored output")
 	rootCmd.PersistentFlags().BoolVar(&cfg.NoEmoji, "no-emoji", false, "Disable emojis")
+	rootCmd.PersistentFlags().StringVar(&cfg.Repo, "repo", "", "GitHub repository owner/name of the PR builds (default: Deployment annotation "+kube.RepoAnnotation+", then "+github.DefaultRepo+")")
+	rootCmd.PersistentFlags().StringVar(&kubeOpts.Kubeconfig, "kubeconfig", "", "Path to kubeconfig (default: $KUBECONFIG, ~/.kube/config, in-cluster)")
+	rootCmd.PersistentFlags().StringVar(&kubeOpts.Context, "context", "", "Kubeconfig context to use")
+	rootCmd.PersistentFlags().StringVarP(&kubeOpts.Namespace, "namespace", "n", kube.DefaultNamespace, "Namespace of the deckhouse installation")
+	rootCmd.PersistentFlags().StringVar(&kubeOpts.Deployment, "deployment", kube.DefaultDeployment, "Name of the deckhouse Deployment")
+	rootCmd.PersistentFlags().StringVar(&kubeOpts.Secret, "registry-secret", kube.DefaultSecret, "Secret with registry credentials (.dockerconfigjson)")
+	rootCmd.PersistentFlags().StringVar(&kubeOpts.Selector, "selector", kube.DefaultSelector, "Label selector of the deckhouse pods")
+	rootCmd.PersistentFlags().StringVar(&kubeOpts.ControllerPort, "controller-port", kube.DefaultControllerPort, "Port of the deckhouse-controller HTTP server (metrics, readiness)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Verbose, "verbose", false, "Print diagnostics such as the GitHub quota left to stderr")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Do not cache GitHub responses on disk")
+	rootCmd.PersistentFlags().DurationVar(&cacheMaxAge, "cache-max-age", time.Minute, "Use cached GitHub responses younger than this without revalidating")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Config profile to use (env "+config.ProfileEnv+")")
 
 	// watch-build flags
 	watchBuildCmd.Flags().IntVar(&watchTimeout, "timeout", 3600, "Timeout in seconds (default 60min)")
 	watchBuildCmd.Flags().BoolVar(&watchRestart, "restart", false, "Restart deckhouse deployment on successful build")
+	watchBuildCmd.Flags().BoolVar(&watchAllChecks, "all-checks", false, "Watch every check-run on the PR head, not only the build check")
+	watchBuildCmd.Flags().StringSliceVar(&watchChecks, "check", nil, "Watch checks matching a glob or /regex/ (repeatable, implies --all-checks)")
+	watchBuildCmd.Flags().StringVar(&watchExitOn, "exit-on", exitOnAll, "With --all-checks: 'all' or 'first-failure'")
+	watchBuildCmd.Flags().BoolVar(&watchFollow, "follow", false, "Follow new commits pushed to the PR while watching")
+	watchBuildCmd.Flags().BoolVar(&watchWaitRollout, "wait-rollout", false, "After restart, wait for the new pod to be Ready and verify its digest (implies --restart)")
+	watchBuildCmd.Flags().BoolVar(&watchConverge, "wait-converge", false, "After the rollout, wait for deckhouse to converge with an empty main queue (implies --wait-rollout)")
+	watchBuildCmd.Flags().IntVar(&watchLogLines, "log-lines", 0, "On build failure, print the last N lines of the job log (requires GITHUB_TOKEN)")
+	watchBuildCmd.Flags().IntVar(&watchRerunOnFailure, "rerun-on-failure", 0, "Re-run failed jobs and keep watching, up to N times (requires GITHUB_TOKEN)")
+
+	// rerun-build flags
+	rerunBuildCmd.Flags().IntVar(&rerunTimeout, "timeout", 30, "Timeout in seconds")
 
 	rootCmd.AddCommand(installMotdCmd)
 	rootCmd.AddCommand(uninstallMotdCmd)
 	rootCmd.AddCommand(editMotdCmd)
 	rootCmd.AddCommand(watchBuildCmd)
+	rootCmd.AddCommand(rerunBuildCmd)
+
+	// status-all flags
+	statusAllCmd.Flags().StringSliceVar(&statusAllContexts, "contexts", nil, "Kubeconfig contexts to check (default: all)")
+	statusAllCmd.Flags().IntVar(&statusAllTimeout, "timeout", 30, "Timeout in seconds for all clusters")
+	rootCmd.AddCommand(statusAllCmd)
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
 }
 
 func main() {
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+// basePollInterval is the delay between polls while quota is plentiful.
+// Tests shorten it.
+var basePollInterval = 10 * time.Second
+
+const (
+	maxPollInterval = 5 * time.Minute
+	// quotaReserve requests are left for other users of the quota, e.g. SSH
+	// logins running the status command on the same node.
+	quotaReserve = 10
+)
+
+// pollDelay returns the wait before the next poll: the base interval while
+// quota is plentiful, the remaining quota spread until its reset once under a
+// quarter is left, or until the limit lifts after a rate-limit error.
+func pollDelay(err error) time.Duration {
+	var rl *github.RateLimitError
+	if errors.As(err, &rl) {
+		return max(rl.Wait(), basePollInterval)
+	}
+
+	lim, ok := ghClient.CoreRateLimit()
+	if !ok || lim.Remaining > lim.Limit/4 {
+		return basePollInterval
+	}
+	untilReset := time.Until(lim.Reset)
+	if lim.Remaining <= quotaReserve {
+		return max(untilReset, basePollInterval)
+	}
+	spread := untilReset / time.Duration(lim.Remaining-quotaReserve)
+	return min(max(spread, basePollInterval), maxPollInterval)
+}
+
+func isRateLimited(err error) bool {
+	var rl *github.RateLimitError
+	return errors.As(err, &rl)
+}
+
+// pollPacer tracks the poll delay of a watch loop and logs when it changes.
+type pollPacer struct {
+	delay     time.Duration
+	remaining int
+	log       func(string)
+}
+
+func newPollPacer(log func(string)) *pollPacer {
+	return &pollPacer{delay: basePollInterval, remaining: -1, log: log}
+}
+
+// update computes the delay after a poll that returned err.
+func (p *pollPacer) update(err error) time.Duration {
+	d := pollDelay(err)
+	lim, ok := ghClient.CoreRateLimit()
+
+	var rl *github.RateLimitError
+	switch {
+	case errors.As(err, &rl):
+		p.log(fmt.Sprintf("GitHub %s, next poll in %s", rl, d.Truncate(time.Second)))
+	case d != p.delay && d == basePollInterval:
+		p.log(fmt.Sprintf("Polling every %s again", d))
+	case d != p.delay && ok:
+		p.log(fmt.Sprintf("GitHub quota low (%d/%d left), polling every %s", lim.Remaining, lim.Limit, d.Truncate(time.Second)))
+	}
+	if cfg.Verbose && ok && lim.Remaining != p.remaining {
+		p.log(quotaLine(lim))
+	}
+
+	p.delay = d
+	if ok {
+		p.remaining = lim.Remaining
+	}
+	return d
+}
+
+func quotaLine(rl github.RateLimit) string {
+	return fmt.Sprintf("GitHub %s quota: %d/%d left, resets at %s", rl.Resource, rl.Remaining, rl.Limit, rl.Reset.Format("15:04:05"))
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+)
+
+// resolveRepo picks the GitHub repository for the cluster's builds: the repo
+// setting (flag, env or config file), then the Deployment annotation, then
+// deckhouse/deckhouse.
+func resolveRepo(cluster *kube.ClusterInfo) (owner, repo string, err error) {
+	ref := cfg.Repo
+	if ref == "" && cluster != nil {
+		ref = cluster.GitHubRepo
+	}
+	if ref == "" {
+		ref = github.DefaultRepo
+	}
+	return github.ParseRepo(ref)
+}
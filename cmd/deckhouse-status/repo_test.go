@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+)
+
+func TestResolveRepo(t *testing.T) {
+	annotated := &kube.ClusterInfo{GitHubRepo: "fork/deckhouse"}
+
+	tests := []struct {
+		name    string
+		flag    string
+		cluster *kube.ClusterInfo
+		want    string
+		wantErr bool
+	}{
+		{name: "setting over annotation", flag: "me/deckhouse", cluster: annotated, want: "me/deckhouse"},
+		{name: "annotation", cluster: annotated, want: "fork/deckhouse"},
+		{name: "default", want: "deckhouse/deckhouse"},
+		{name: "invalid", flag: "deckhouse", wantErr: true},
+		{name: "too many parts", flag: "github.com/deckhouse/deckhouse", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := cfg.Repo
+			t.Cleanup(func() { cfg.Repo = saved })
+			cfg.Repo = tt.flag
+
+			owner, repo, err := resolveRepo(tt.cluster)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveRepo = %s/%s, want error", owner, repo)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := owner + "/" + repo; got != tt.want {
+				t.Errorf("resolveRepo = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+var (
+	rerunTimeout        int
+	watchRerunOnFailure int
+)
+
+var rerunBuildCmd = &cobra.Command{
+	Use:   "rerun-build",
+	Short: "Re-run the failed jobs of the current PR's CI build",
+	Long: `Finds the workflow run behind the build check-run of the PR head ("Build
+<edition>" unless tag-rules say otherwise) and re-runs its failed jobs. Requires GITHUB_TOKEN with actions:write.
+
+Exits with code 0 when the re-run was requested, 1 when the build has not
+failed (still running or passed), 2 on error.
+
+watch-build --rerun-on-failure N does the same automatically and keeps
+watching, up to N times.`,
+	Run: runRerunBuild,
+}
+
+func runRerunBuild(cmd *cobra.Command, args []string) {
+	if !ghClient.HasToken() {
+		fmt.Fprintln(os.Stderr, "Error: rerun-build requires GITHUB_TOKEN")
+		os.Exit(2)
+	}
+	ghClient.SetCacheMaxAge(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rerunTimeout)*time.Second)
+	defer cancel()
+
+	target, err := resolveWatchTarget(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	cr, err := ghClient.PollCheckRun(ctx, github.PollCheckRunRequest{
+		Owner:     target.owner,
+		Repo:      target.repo,
+		SHA:       target.sha,
+		CheckName: target.checkName,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	switch {
+	case cr.ID == 0:
+		fmt.Fprintf(os.Stderr, "%s has not started for %s\n", target.checkName, github.ShortSHA(target.sha))
+		os.Exit(1)
+	case cr.Status != "completed":
+		fmt.Fprintf(os.Stderr, "%s is still running (%s)\n", target.checkName, cr.Status)
+		os.Exit(1)
+	case github.IsPassing(cr.Conclusion):
+		fmt.Fprintf(os.Stderr, "%s passed, nothing to re-run\n", target.checkName)
+		os.Exit(1)
+	}
+
+	spinner := display.NewSpinner(cfg.NoColor, cfg.NoEmoji)
+	if err := rerunBuild(ctx, target, cr.ID); err != nil {
+		spinner.Failure(fmt.Sprintf("Error: %v", err))
+		os.Exit(2)
+	}
+	spinner.Success(fmt.Sprintf("Re-run of %s requested for PR #%d (%s)", target.checkName, target.prNumber, github.ShortSHA(target.sha)))
+}
+
+// rerunBuild re-runs the failed jobs of the workflow run behind a check-run.
+func rerunBuild(ctx context.Context, target *watchTarget, checkRunID int64) error {
+	runID, err := ghClient.FetchWorkflowRunID(ctx, target.owner, target.repo, checkRunID)
+	if err != nil {
+		return err
+	}
+	return ghClient.RerunFailedJobs(ctx, target.owner, target.repo, runID)
+}
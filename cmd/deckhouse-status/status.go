@@ -11,40 +11,105 @@ import (
 
 	"github.com/glitchy-sheep/deckhouse-status/internal/display"
 	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/imagetag"
 	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
-	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
 )
 
 func runStatus(cmd *cobra.Command, args []string) {
+	if cfg.Output != display.OutputText && cfg.Output != display.OutputJSON {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q (want %q or %q)\n", cfg.Output, display.OutputText, display.OutputJSON)
+		os.Exit(1)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
 	defer cancel()
 
-	client, err := kube.NewClient()
+	// Without --exit-code every failure is 1, as before.
+	errCode := 1
+	if statusExitCodes {
+		errCode = exitError
+	}
+
+	client, err := newKubeClient(kubeOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(errCode)
 	}
 
-	cluster, err := client.FetchClusterInfo(ctx)
+	fetchPR := func(ctx context.Context, owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error) {
+		return ghClient.FetchPRInfo(ctx, owner, repo, prNumber, checkNames, cfg.Short)
+	}
+
+	data, err := collectStatus(ctx, client, fetchPR, statusOptions())
+	printDiagnostics()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(errCode)
+	}
+
+	p := display.NewPrinter(cfg)
+	if err := p.Render(data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(errCode)
+	}
+
+	if statusExitCodes {
+		os.Exit(statusExitCode(data))
+	}
+}
+
+// prFetcher looks up PR info; status-all swaps in a deduplicating one.
+type prFetcher func(ctx context.Context, owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error)
+
+// collectOptions selects the lookups of collectStatus that only some outputs
+// show.
+type collectOptions struct {
+	controller bool // deckhouse-controller queue and converge state
+	compare    bool // commits between the deployed and the head commit, for the verdict reason
+}
+
+// statusOptions returns what the status output shows.
+func statusOptions() collectOptions {
+	return collectOptions{
+		// The short output has no DECKHOUSE section.
+		controller: !cfg.Short || cfg.Output == display.OutputJSON,
+		compare:    true,
+	}
+}
+
+// collectStatus runs the cluster → GitHub + registry pipeline for one cluster.
+func collectStatus(ctx context.Context, client *kube.Client, fetchPR prFetcher, opts collectOptions) (display.RenderData, error) {
+	cluster, err := client.FetchClusterInfo(ctx)
+	if err != nil {
+		return display.RenderData{}, err
 	}
 
-	prNumber, edition := display.ParsePRTag(cluster.Tag)
+	tag := tagRules.Parse(cluster.Repository, cluster.Tag)
+
+	owner, repo, err := resolveRepo(cluster)
+	if err != nil {
+		return display.RenderData{}, err
+	}
 
-	var (
-		prInfo *github.PRInfo
-		prErr  error
-		regRes *registry.Result
-		wg     sync.WaitGroup
-	)
+	data := display.RenderData{
+		Cluster: cluster,
+		Tag:     tag,
+		Repo:    owner + "/" + repo,
+	}
+	var wg sync.WaitGroup
 
-	if prNumber > 0 && !cfg.NoGitHub {
+	if tag.Kind != imagetag.Unknown && !cfg.NoGitHub {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			prInfo, prErr = github.FetchPRInfo(ctx, "deckhouse", "deckhouse", prNumber, "Build "+edition, cfg.Short)
+			switch tag.Kind {
+			case imagetag.PR:
+				data.PR, data.GitHubErr = fetchPR(ctx, owner, repo, tag.PRNumber, tag.Checks)
+			case imagetag.Branch:
+				data.Branch, data.GitHubErr = ghClient.FetchBranchInfo(ctx, owner, repo, tag.Branch, tag.Checks)
+			case imagetag.Release:
+				data.Release, data.GitHubErr = ghClient.FetchReleaseInfo(ctx, owner, repo, tag.Version)
+			}
 		}()
 	}
 
@@ -52,19 +117,29 @@ func runStatus(cmd *cobra.Command, args []string) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			regRes = registry.Check(ctx, cluster.Registry, cluster.Repository, cluster.Tag, cluster.RunningDigest, cluster.RegistryCreds)
+			data.Registry = regClient.Check(ctx, cluster.Registry, cluster.Repository, cluster.Tag, cluster.RunningDigest, cluster.Platform, cluster.RegistryCreds)
+		}()
+	}
+
+	// A pod that is not running has no controller to ask.
+	if opts.controller && cluster.PodPhase == "Running" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data.Controller, data.ControllerErr = client.FetchControllerStatus(ctx, cluster.PodName)
 		}()
 	}
 
 	wg.Wait()
 
-	p := display.NewPrinter(cfg)
-	p.Render(display.RenderData{
-		Cluster:  cluster,
-		PRNumber: prNumber,
-		Edition:  edition,
-		PR:       prInfo,
-		PRErr:    prErr,
-		Registry: regRes,
-	})
+	// Image labels describe what actually runs; the annotation is a fallback
+	// for images built without them.
+	data.DeployedSHA = cluster.Commit
+	if data.Registry != nil && data.Registry.Revision != "" {
+		data.DeployedSHA = data.Registry.Revision
+	}
+	if head := data.Head(); opts.compare && head != nil && data.DeployedSHA != "" && data.DeployedSHA != head.HeadSHA {
+		data.Compare, data.CompareErr = ghClient.FetchCompare(ctx, owner, repo, data.DeployedSHA, head.HeadSHA)
+	}
+	return data, nil
 }
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/display"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+)
+
+var (
+	statusAllContexts []string
+	statusAllTimeout  int
+)
+
+var statusAllCmd = &cobra.Command{
+	Use:   "status-all",
+	Short: "Show a one-line status for every cluster in the kubeconfig",
+	Long: `Runs the status check against several clusters at once and prints a
+compact table: one row per cluster with tag, PR, edition, pod age and verdict.
+
+Clusters are all kubeconfig contexts, or the ones given with --contexts
+(also settable as "contexts" in the config file; --timeout as
+"status-all-timeout"). GitHub is queried once per PR even when several
+clusters run the same build; with GITHUB_TOKEN all PRs are fetched in a
+single GraphQL request.`,
+	Run: runStatusAll,
+}
+
+func runStatusAll(cmd *cobra.Command, args []string) {
+	contexts := statusAllContexts
+	if len(contexts) == 0 {
+		var err error
+		contexts, err = kube.Contexts(kubeOpts.Kubeconfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(contexts) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no kubeconfig contexts found")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(statusAllTimeout)*time.Second)
+	defer cancel()
+
+	batcher := newPRBatcher(ctx, len(contexts))
+
+	rows := make([]display.ClusterRow, len(contexts))
+	var wg sync.WaitGroup
+	for i, name := range contexts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchPR, leave := batcher.forCluster()
+			defer leave()
+			rows[i] = collectCluster(ctx, name, fetchPR)
+		}()
+	}
+	wg.Wait()
+	printDiagnostics()
+
+	display.NewPrinter(cfg).RenderTable(rows)
+}
+
+func collectCluster(ctx context.Context, name string, fetchPR prFetcher) display.ClusterRow {
+	opts := kubeOpts
+	opts.Context = name
+
+	client, err := newKubeClient(opts)
+	if err != nil {
+		return display.ClusterRow{Name: name, Err: err}
+	}
+
+	// The table shows neither the controller state nor the verdict reason of
+	// an outdated commit, which the comparison is for.
+	data, err := collectStatus(ctx, client, fetchPR, collectOptions{})
+	if err != nil {
+		return display.ClusterRow{Name: name, Err: err}
+	}
+	return display.ClusterRow{Name: name, Data: &data}
+}
+
+// prBatchWait bounds how long a PR lookup waits for slower clusters to join its batch.
+const prBatchWait = 2 * time.Second
+
+// prBatcher collects the PR lookups of all clusters and sends them as one
+// batch (a single GraphQL query with GITHUB_TOKEN) once every cluster has
+// asked or finished without asking, or prBatchWait after the first request.
+// Clusters running the same PR build share one lookup.
+type prBatcher struct {
+	ctx context.Context
+
+	mu        sync.Mutex
+	remaining int // clusters that have neither asked nor left
+	entries   map[string]*prEntry
+	pending   []*prEntry // not sent yet
+	timer     *time.Timer
+}
+
+type prEntry struct {
+	req  github.PRRequest
+	done chan struct{}
+	info *github.PRInfo
+	err  error
+}
+
+func newPRBatcher(ctx context.Context, clusters int) *prBatcher {
+	return &prBatcher{ctx: ctx, remaining: clusters, entries: make(map[string]*prEntry)}
+}
+
+// forCluster returns the fetcher for one cluster and a func that must be
+// called when the cluster is done, so the batch does not wait for it.
+func (b *prBatcher) forCluster() (prFetcher, func()) {
+	var asked bool
+	fetch := func(ctx context.Context, owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error) {
+		asked = true
+		return b.fetch(owner, repo, prNumber, checkNames)
+	}
+	leave := func() {
+		if !asked {
+			b.leave()
+		}
+	}
+	return fetch, leave
+}
+
+func (b *prBatcher) fetch(owner, repo string, prNumber int, checkNames []string) (*github.PRInfo, error) {
+	key := fmt.Sprintf("%s/%s#%d/%s", owner, repo, prNumber, strings.Join(checkNames, "|"))
+
+	b.mu.Lock()
+	e, ok := b.entries[key]
+	if !ok {
+		e = &prEntry{
+			req:  github.PRRequest{Owner: owner, Repo: repo, Number: prNumber, BuildCheckNames: checkNames},
+			done: make(chan struct{}),
+		}
+		b.entries[key] = e
+		b.pending = append(b.pending, e)
+		if b.timer == nil {
+			b.timer = time.AfterFunc(prBatchWait, b.flush)
+		}
+	}
+	b.remaining--
+	ready := b.remaining == 0
+	b.mu.Unlock()
+
+	if ready {
+		b.flush()
+	}
+	<-e.done
+	return e.info, e.err
+}
+
+func (b *prBatcher) leave() {
+	b.mu.Lock()
+	b.remaining--
+	ready := b.remaining == 0
+	b.mu.Unlock()
+
+	if ready {
+		b.flush()
+	}
+}
+
+// flush sends the pending lookups and wakes their waiters.
+func (b *prBatcher) flush() {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+	reqs := make([]github.PRRequest, len(batch))
+	for i, e := range batch {
+		reqs[i] = e.req
+	}
+	// The table has no room for commit details, so REST skips that call.
+	results := ghClient.FetchPRInfoBatch(b.ctx, reqs, true)
+	for i, e := range batch {
+		e.info, e.err = results[i].Info, results[i].Err
+		close(e.done)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/httpretry"
+)
+
+// printDiagnostics prints the GitHub quota left and the HTTP requests sent
+// per host, with their retries, to stderr with --verbose.
+func printDiagnostics() {
+	if !cfg.Verbose {
+		return
+	}
+	for _, rl := range ghClient.RateLimits() {
+		fmt.Fprintln(os.Stderr, quotaLine(rl))
+	}
+	for _, s := range httpretry.Stats() {
+		fmt.Fprintf(os.Stderr, "HTTP %s: %d requests, %d attempts\n", s.Host, s.Requests, s.Attempts)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -12,12 +13,17 @@ import (
 
 	"github.com/glitchy-sheep/deckhouse-status/internal/display"
 	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/imagetag"
 	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
 )
 
 var (
-	watchTimeout int
-	watchRestart bool
+	watchTimeout     int
+	watchRestart     bool
+	watchWaitRollout bool
+	watchConverge    bool
+	watchFollow      bool
+	watchLogLines    int
 )
 
 var watchBuildCmd = &cobra.Command{
@@ -27,21 +33,57 @@ var watchBuildCmd = &cobra.Command{
 showing a live spinner with elapsed time. Exits with code 0 on success,
 1 on build failure, 2 on error/timeout.
 
+With --restart the deckhouse Deployment is restarted after a successful build.
+--wait-rollout (implies --restart) then waits until the new pod is Running and
+Ready and checks that it runs the registry digest of the tag. A stalled
+rollout (ImagePullBackOff, CrashLoopBackOff, progress deadline exceeded) or a
+digest mismatch exits with 1. --wait-converge (implies --wait-rollout) also
+waits until deckhouse-controller in the new pod has converged: its first
+converge after the start is done and the main queue is empty.
+
+With --follow the PR head is re-checked periodically; when someone pushes a
+new commit the watch switches to that commit's build and only finishes when
+the latest head's build does.
+
+--all-checks watches every check-run on the PR head instead of only the
+build check ("Build <edition>" by default, see tag-rules), on a live board
+with one line per check. --check limits it to matching names (glob, or
+/regex/; repeatable) and requires each pattern to match a run. --exit-on all
+(default) waits for every check and fails if any failed; --exit-on
+first-failure fails as soon as one does.
+
+When the build fails, the failed job steps, the first error annotations and a
+link to the job log are printed. --log-lines N also prints the last N lines of
+the job log (requires GITHUB_TOKEN).
+
+--rerun-on-failure N re-runs the failed jobs of the build (like rerun-build)
+and keeps watching, up to N times. Requires GITHUB_TOKEN; not supported with
+--all-checks.
+
 Uses ETag conditional requests to minimize GitHub API rate limit usage
-(304 Not Modified responses are free).`,
+(304 Not Modified responses are free). Polls run every 10s; once less than a
+quarter of the hourly quota is left they slow down to spread the rest until
+the reset, and a rate-limit response (including secondary limits and
+Retry-After) pauses polling until GitHub allows requests again. --verbose
+logs the quota left.`,
 	Run: runWatchBuild,
 }
 
 type watchTarget struct {
-	client    *kube.Client
-	prNumber  int
-	edition   string
-	sha       string
-	checkName string
+	client   *kube.Client
+	cluster  *kube.ClusterInfo
+	owner    string
+	repo     string
+	prNumber int
+	sha      string
+	// checkNames are the candidate build check names from the tag rules;
+	// checkName is the one watched.
+	checkNames []string
+	checkName  string
 }
 
 func resolveWatchTarget(ctx context.Context) (*watchTarget, error) {
-	client, err := kube.NewClient()
+	client, err := newKubeClient(kubeOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -53,26 +95,59 @@ func resolveWatchTarget(ctx context.Context) (*watchTarget, error) {
 		return nil, err
 	}
 
-	prNumber, edition := display.ParsePRTag(cluster.Tag)
-	if prNumber == 0 {
+	tag := tagRules.Parse(cluster.Repository, cluster.Tag)
+	if tag.Kind != imagetag.PR {
 		return nil, fmt.Errorf("image tag %q is not a PR tag", cluster.Tag)
 	}
 
-	sha, err := github.FetchHeadSHA(ctx, "deckhouse", "deckhouse", prNumber)
+	owner, repo, err := resolveRepo(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	sha, err := ghClient.FetchHeadSHA(ctx, owner, repo, tag.PRNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	return &watchTarget{
-		client:    client,
-		prNumber:  prNumber,
-		edition:   edition,
-		sha:       sha,
-		checkName: "Build " + edition,
-	}, nil
+	target := &watchTarget{
+		client:     client,
+		cluster:    cluster,
+		owner:      owner,
+		repo:       repo,
+		prNumber:   tag.PRNumber,
+		sha:        sha,
+		checkNames: tag.Checks,
+		checkName:  tag.Checks[0],
+	}
+	target.checkName = findCheckName(ctx, target)
+	return target, nil
+}
+
+// findCheckName returns the candidate build check name that exists on
+// target.sha, or the first one while none does. On errors the current name
+// is kept.
+func findCheckName(ctx context.Context, target *watchTarget) string {
+	if len(target.checkNames) < 2 {
+		return target.checkName
+	}
+	_, name, err := ghClient.FindCheckRun(ctx, target.owner, target.repo, target.sha, target.checkNames)
+	if err != nil {
+		return target.checkName
+	}
+	return name
 }
 
 func runWatchBuild(cmd *cobra.Command, args []string) {
+	if watchConverge {
+		watchWaitRollout = true
+	}
+	if watchWaitRollout {
+		watchRestart = true
+	}
+	// Polling needs current data; ETags keep revalidation cheap.
+	ghClient.SetCacheMaxAge(0)
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(watchTimeout)*time.Second)
 	defer cancel()
 
@@ -83,72 +158,158 @@ func runWatchBuild(cmd *cobra.Command, args []string) {
 		cancel()
 	}()
 
+	if watchRerunOnFailure > 0 && !ghClient.HasToken() {
+		fmt.Fprintln(os.Stderr, "Error: --rerun-on-failure requires GITHUB_TOKEN")
+		os.Exit(2)
+	}
+
 	target, err := resolveWatchTarget(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(2)
 	}
 
+	if watchAllChecks || len(watchChecks) > 0 {
+		if watchRerunOnFailure > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --rerun-on-failure is not supported with --all-checks or --check")
+			os.Exit(2)
+		}
+		os.Exit(watchAllChecksLoop(ctx, target))
+	}
+	os.Exit(watchBuild(ctx, target))
+}
+
+// watchBuild polls the build check-run of target until it completes, re-runs
+// it on failure if asked to, and returns the exit code.
+func watchBuild(ctx context.Context, target *watchTarget) int {
 	p := display.NewPrinter(cfg)
-	p.PrintWatchHeader(target.prNumber, target.edition, target.sha)
+	p.PrintWatchHeader(target.prNumber, target.checkName, target.sha)
 
 	spinner := display.NewSpinner(cfg.NoColor, cfg.NoEmoji)
 
 	pollReq := github.PollCheckRunRequest{
-		Owner:     "deckhouse",
-		Repo:      "deckhouse",
+		Owner:     target.owner,
+		Repo:      target.repo,
 		SHA:       target.sha,
 		CheckName: target.checkName,
 	}
 
-	// First poll immediately
-	lastResult, err := pollCheckRun(ctx, pollReq, spinner)
-	if err != nil {
-		spinner.Failure(fmt.Sprintf("Error: %v", err))
-		os.Exit(2)
-	}
-	pollReq.ETag = lastResult.ETag
-	if code, done := checkBuildDone(lastResult, target.checkName, spinner); done {
-		if code == 0 && watchRestart {
-			doRestart(ctx, target.client, spinner)
-		}
-		os.Exit(code)
+	headReq := github.PollHeadSHARequest{
+		Owner:    target.owner,
+		Repo:     target.repo,
+		PRNumber: target.prNumber,
 	}
 
-	const pollInterval = 10 * time.Second
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+	// With --follow the PR head is re-checked every headCheckEvery polls
+	// (ETag makes unchanged heads free) and always before finishing.
+	const headCheckEvery = 3
+	pacer := newPollPacer(func(msg string) { spinner.Log(msg) })
+	delay := basePollInterval
 
-	for {
-		select {
-		case <-ctx.Done():
-			spinner.ClearLine()
-			if ctx.Err() == context.DeadlineExceeded {
-				spinner.Failure("Timeout waiting for build to complete")
-			} else {
-				fmt.Fprintf(os.Stderr, "\nInterrupted.\n")
+	// After a re-run the failed check-run is still the latest one until
+	// GitHub creates its replacement; staleID skips it.
+	var (
+		reruns  int
+		staleID int64
+	)
+	// Until the build check appears on the commit it may show up under any
+	// of the candidate names.
+	found := false
+
+	for polls := 0; ; polls++ {
+		// First poll immediately
+		if polls > 0 {
+			select {
+			case <-ctx.Done():
+				spinner.ClearLine()
+				if ctx.Err() == context.DeadlineExceeded {
+					spinner.Failure("Timeout waiting for build to complete")
+				} else {
+					fmt.Fprintf(os.Stderr, "\nInterrupted.\n")
+				}
+				return 2
+			case <-time.After(delay):
 			}
-			os.Exit(2)
+		}
 
-		case <-ticker.C:
-			lastResult, err = pollCheckRun(ctx, pollReq, spinner)
-			if err != nil {
-				spinner.Tick(fmt.Sprintf("%s: error (%v), retrying...", target.checkName, err))
+		lastResult, err := pollCheckRun(ctx, pollReq, spinner)
+		delay = pacer.update(err)
+		if err != nil {
+			if polls == 0 && !isRateLimited(err) {
+				spinner.Failure(fmt.Sprintf("Error: %v", err))
+				return 2
+			}
+			spinner.Tick(fmt.Sprintf("%s: error (%v), retrying...", target.checkName, err))
+			continue
+		}
+		pollReq.ETag = lastResult.ETag
+		if staleID != 0 && lastResult.ID == staleID {
+			spinner.Tick(fmt.Sprintf("%s: waiting for re-run to start...", target.checkName))
+			continue
+		}
+		found = found || lastResult.ID != 0
+		if !found {
+			if name := findCheckName(ctx, target); name != target.checkName {
+				target.checkName = name
+				pollReq.CheckName = name
+				pollReq.ETag = ""
+				continue
+			}
+		}
+
+		if watchFollow && (lastResult.Status == "completed" || polls%headCheckEvery == headCheckEvery-1) {
+			if followHead(ctx, target, &headReq, target.checkName, spinner.Log) {
+				pollReq.SHA = target.sha
+				pollReq.ETag = ""
+				found = false
+				spinner.Tick(fmt.Sprintf("%s: waiting for check to appear...", target.checkName))
 				continue
 			}
-			pollReq.ETag = lastResult.ETag
-			if code, done := checkBuildDone(lastResult, target.checkName, spinner); done {
-				if code == 0 && watchRestart {
-					doRestart(ctx, target.client, spinner)
+		}
+
+		if code, done := checkBuildDone(lastResult, target.checkName, spinner); done {
+			if code != 0 && lastResult.Conclusion != "cancelled" {
+				printBuildFailure(ctx, target, lastResult.ID, p)
+			}
+			if code != 0 && reruns < watchRerunOnFailure {
+				reruns++
+				if err := rerunBuild(ctx, target, lastResult.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "Re-run failed: %v\n", err)
+					return code
 				}
-				os.Exit(code)
+				fmt.Fprintf(os.Stderr, "\nRe-running failed jobs of %s (attempt %d/%d)\n\n", target.checkName, reruns, watchRerunOnFailure)
+				staleID = lastResult.ID
+				pollReq.ETag = ""
+				spinner = display.NewSpinner(cfg.NoColor, cfg.NoEmoji)
+				spinner.Tick(fmt.Sprintf("%s: waiting for re-run to start...", target.checkName))
+				continue
 			}
+			return afterBuild(ctx, target, code)
 		}
 	}
 }
 
+// followHead re-checks the PR head and, if it moved, points target at the new
+// commit. It reports whether a switch happened; callers reset their polling.
+func followHead(ctx context.Context, target *watchTarget, headReq *github.PollHeadSHARequest, what string, log func(string)) bool {
+	res, err := ghClient.PollHeadSHA(ctx, *headReq)
+	if err != nil {
+		log(fmt.Sprintf("PR head check failed: %v", err))
+		return false
+	}
+	headReq.ETag = res.ETag
+	if res.NotModified || res.SHA == "" || res.SHA == target.sha {
+		return false
+	}
+
+	log(fmt.Sprintf("PR head moved %s → %s, watching %s of the new commit",
+		github.ShortSHA(target.sha), github.ShortSHA(res.SHA), what))
+	target.sha = res.SHA
+	return true
+}
+
 func pollCheckRun(ctx context.Context, req github.PollCheckRunRequest, spinner *display.Spinner) (*github.CheckRunResult, error) {
-	result, err := github.PollCheckRun(ctx, req)
+	result, err := ghClient.PollCheckRun(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +361,48 @@ func checkBuildDone(status *github.CheckRunResult, checkName string, spinner *di
 	}
 }
 
-func doRestart(ctx context.Context, client *kube.Client, spinner *display.Spinner) {
+// printBuildFailure explains a failed check-run. Errors are reported but do not
+// change the exit code: the build result is already known.
+func printBuildFailure(ctx context.Context, target *watchTarget, checkRunID int64, p *display.Printer) {
+	if checkRunID == 0 {
+		return
+	}
+
+	f, err := ghClient.FetchFailure(ctx, target.owner, target.repo, checkRunID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not fetch failure details: %v\n", err)
+		return
+	}
+
+	var tail []string
+	if watchLogLines > 0 && f.RunID != 0 {
+		if tail, err = ghClient.FetchJobLogTail(ctx, target.owner, target.repo, checkRunID, watchLogLines); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not fetch job log: %v\n", err)
+		}
+	}
+	p.PrintFailure(f, tail)
+}
+
+// afterBuild runs the optional restart and rollout steps and returns the exit code.
+func afterBuild(ctx context.Context, target *watchTarget, buildCode int) int {
+	if buildCode != 0 || !watchRestart {
+		return buildCode
+	}
+
+	// The exit code reports the build: a failed restart is printed, not
+	// returned, and leaves no rollout to wait for.
+	spinner := display.NewSpinner(cfg.NoColor, cfg.NoEmoji)
+	if !doRestart(ctx, target.client, spinner) || !watchWaitRollout {
+		return 0
+	}
+	pod, code := waitRollout(ctx, target, display.NewSpinner(cfg.NoColor, cfg.NoEmoji))
+	if code != 0 || !watchConverge {
+		return code
+	}
+	return waitConverge(ctx, target.client, pod, display.NewSpinner(cfg.NoColor, cfg.NoEmoji))
+}
+
+func doRestart(ctx context.Context, client *kube.Client, spinner *display.Spinner) bool {
 	fmt.Fprintf(os.Stderr, "Restarting deckhouse deployment...\n")
 
 	restartCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
@@ -208,7 +410,118 @@ func doRestart(ctx context.Context, client *kube.Client, spinner *display.Spinne
 
 	if err := client.RestartDeployment(restartCtx); err != nil {
 		spinner.Failure(fmt.Sprintf("Restart failed: %v", err))
-	} else {
-		spinner.Success("Deployment restarted")
+		return false
+	}
+	spinner.Success("Deployment restarted")
+	return true
+}
+
+// rolloutPollInterval is the delay between polls of the rollout and converge.
+var rolloutPollInterval = 3 * time.Second
+
+// waitRollout polls the rollout until the new pod is Ready, then compares its
+// digest with the registry. It returns the new pod's name and an exit code
+// following watch-build: 1 stalled/mismatch, 2 error/timeout.
+func waitRollout(ctx context.Context, target *watchTarget, spinner *display.Spinner) (string, int) {
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	spinner.Tick("Rollout: starting...")
+	for {
+		select {
+		case <-ctx.Done():
+			spinner.ClearLine()
+			if ctx.Err() == context.DeadlineExceeded {
+				spinner.Failure("Timeout waiting for rollout to complete")
+			} else {
+				fmt.Fprintf(os.Stderr, "\nInterrupted.\n")
+			}
+			return "", 2
+
+		case <-ticker.C:
+			st, err := target.client.RolloutStatus(ctx)
+			if err != nil {
+				spinner.Tick(fmt.Sprintf("Rollout: error (%v), retrying...", err))
+				continue
+			}
+			if st.Stalled != "" {
+				spinner.Failure(fmt.Sprintf("Rollout stalled: %s", st.Stalled))
+				return "", 1
+			}
+			if !st.Done {
+				spinner.Tick("Rollout: " + st.Message)
+				continue
+			}
+			return st.NewPod.Name, verifyRolloutDigest(ctx, target, st.NewPod, spinner)
+		}
+	}
+}
+
+func verifyRolloutDigest(ctx context.Context, target *watchTarget, pod *kube.RolloutPod, spinner *display.Spinner) int {
+	c := target.cluster
+	reg := regClient.Check(ctx, c.Registry, c.Repository, c.Tag, pod.RunningDigest, c.Platform, c.RegistryCreds)
+
+	switch {
+	case reg.Err != nil:
+		spinner.Failure(fmt.Sprintf("Pod %s is Ready, but registry check failed: %v", pod.Name, reg.Err))
+		return 2
+	case !reg.TagExists:
+		spinner.Failure(fmt.Sprintf("Pod %s is Ready, but tag %s is gone from the registry", pod.Name, c.Tag))
+		return 1
+	case !reg.DigestMatch:
+		spinner.Failure(fmt.Sprintf("Pod %s runs %s, registry has %s for %s", pod.Name, pod.RunningDigest, reg.Digest, c.Tag))
+		return 1
+	}
+
+	spinner.Success(fmt.Sprintf("Pod %s is Ready and runs the latest %s image", pod.Name, c.Tag))
+	return 0
+}
+
+// waitConverge polls deckhouse-controller in pod until its first converge is
+// done and the main queue is empty. Module errors are reported but do not
+// fail the wait. Exit codes follow watch-build: 2 timeout.
+func waitConverge(ctx context.Context, client *kube.Client, pod string, spinner *display.Spinner) int {
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	spinner.Tick("Converge: starting...")
+	for {
+		select {
+		case <-ctx.Done():
+			spinner.ClearLine()
+			if ctx.Err() == context.DeadlineExceeded {
+				spinner.Failure("Timeout waiting for deckhouse to converge")
+			} else {
+				fmt.Fprintf(os.Stderr, "\nInterrupted.\n")
+			}
+			return 2
+
+		case <-ticker.C:
+			st, err := client.FetchControllerStatus(ctx, pod)
+			if err != nil {
+				// The controller may not listen yet right after the start.
+				spinner.Tick(fmt.Sprintf("Converge: controller unavailable (%v), retrying...", err))
+				continue
+			}
+			switch st.Converge {
+			case kube.ConvergeStartup:
+				spinner.Tick(fmt.Sprintf("Converge: first converge after start, %d tasks in the main queue", st.MainQueue))
+				continue
+			case kube.ConvergeInProgress:
+				spinner.Tick(fmt.Sprintf("Converge: %d tasks in the main queue", st.MainQueue))
+				continue
+			}
+
+			msg := "Deckhouse converged, main queue is empty"
+			if len(st.ModuleErrors) > 0 {
+				modules := make([]string, len(st.ModuleErrors))
+				for i, m := range st.ModuleErrors {
+					modules[i] = m.String()
+				}
+				msg += "; hook errors since start: " + strings.Join(modules, ", ")
+			}
+			spinner.Success(msg)
+			return 0
+		}
 	}
 }
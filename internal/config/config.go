@@ -0,0 +1,361 @@
+// Package config loads persistent settings from config files and the environment.
+//
+// Settings are keyed by command-line flag name, so every key maps to exactly one
+// flag and values are parsed by the flag itself. Precedence, highest first:
+// flags, environment, user config, system config, flag default.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SystemPath is the machine-wide config file.
+	SystemPath = "/etc/deckhouse-status/config.yaml"
+
+	// EnvPrefix is prepended to the upper-cased key to form its environment variable:
+	// "no-github" → DECKHOUSE_STATUS_NO_GITHUB.
+	EnvPrefix = "DECKHOUSE_STATUS_"
+
+	// ProfileEnv selects a profile when --profile is not given.
+	ProfileEnv = EnvPrefix + "PROFILE"
+
+	profilesKey = "profiles"
+
+	// TagRulesKey is the list of image tag rules. Unlike the other keys it
+	// has no flag and no environment variable; read it with Section.
+	TagRulesKey = "tag-rules"
+)
+
+// systemPath is SystemPath; tests point it elsewhere with SetSystemPath.
+var systemPath = SystemPath
+
+// SetSystemPath replaces the system config path and returns a function that
+// restores it. It lets tests of other packages ignore the machine's config.
+func SetSystemPath(path string) (restore func()) {
+	saved := systemPath
+	systemPath = path
+	return func() { systemPath = saved }
+}
+
+// sectionKeys hold structured values that Apply skips.
+var sectionKeys = map[string]bool{TagRulesKey: true}
+
+// Source says where an effective value came from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceUser    Source = "user config"
+	SourceSystem  Source = "system config"
+)
+
+// Origin describes where a setting's effective value came from.
+type Origin struct {
+	Source  Source
+	Path    string // config file or environment variable name
+	Profile string // profile the value was taken from, empty for top-level keys
+}
+
+func (o Origin) String() string {
+	switch {
+	case o.Path == "":
+		return string(o.Source)
+	case o.Profile != "":
+		return fmt.Sprintf("%s (%s, profile %s)", o.Source, o.Path, o.Profile)
+	default:
+		return fmt.Sprintf("%s (%s)", o.Source, o.Path)
+	}
+}
+
+// Setting is the effective value of a single key.
+type Setting struct {
+	Key    string
+	Value  string
+	Origin Origin
+}
+
+// layer is one source of values, e.g. one config file.
+type layer struct {
+	source   Source
+	path     string
+	values   map[string]string
+	profiles map[string]map[string]string
+}
+
+// UserPath returns the per-user config file: $XDG_CONFIG_HOME/deckhouse-status/config.yaml,
+// falling back to ~/.config.
+func UserPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "deckhouse-status", "config.yaml")
+}
+
+// EnvName returns the environment variable for key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Apply fills every flag in keys that was not set on the command line from the
+// environment and config files, using profile if non-empty. lookup resolves a
+// key to its flag. It returns the effective settings in keys order.
+func Apply(keys []string, lookup func(string) *pflag.Flag, profile string) ([]Setting, error) {
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[k] = true
+	}
+
+	layers := []*layer{envLayer(keys)}
+	for _, f := range []struct {
+		source Source
+		path   string
+	}{
+		{SourceUser, UserPath()},
+		{SourceSystem, systemPath},
+	} {
+		if f.path == "" {
+			continue
+		}
+		l, err := loadFile(f.source, f.path, known)
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			layers = append(layers, l)
+		}
+	}
+
+	if profile != "" && !profileExists(layers, profile) {
+		return nil, fmt.Errorf("profile %q not found in %s or %s", profile, UserPath(), systemPath)
+	}
+
+	settings := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		f := lookup(key)
+		if f == nil {
+			return nil, fmt.Errorf("config key %q has no flag", key)
+		}
+
+		origin := Origin{Source: SourceDefault}
+		if f.Changed {
+			origin = Origin{Source: SourceFlag}
+		} else if val, o, ok := resolve(layers, key, profile); ok {
+			if err := f.Value.Set(val); err != nil {
+				return nil, fmt.Errorf("%s: invalid value %q for %s: %w", o.Path, val, key, err)
+			}
+			origin = o
+		}
+
+		settings = append(settings, Setting{Key: key, Value: f.Value.String(), Origin: origin})
+	}
+	return settings, nil
+}
+
+// resolve returns the value of key from the highest-precedence layer. Within a
+// file the selected profile overrides top-level keys.
+func resolve(layers []*layer, key, profile string) (string, Origin, bool) {
+	for _, l := range layers {
+		if profile != "" {
+			if v, ok := l.profiles[profile][key]; ok {
+				return v, Origin{Source: l.source, Path: l.path, Profile: profile}, true
+			}
+		}
+		if v, ok := l.values[key]; ok {
+			path := l.path
+			if l.source == SourceEnv {
+				path = EnvName(key)
+			}
+			return v, Origin{Source: l.source, Path: path}, true
+		}
+	}
+	return "", Origin{}, false
+}
+
+func profileExists(layers []*layer, profile string) bool {
+	for _, l := range layers {
+		if _, ok := l.profiles[profile]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func envLayer(keys []string) *layer {
+	l := &layer{source: SourceEnv, values: make(map[string]string)}
+	for _, key := range keys {
+		if v, ok := os.LookupEnv(EnvName(key)); ok {
+			l.values[key] = v
+		}
+	}
+	return l
+}
+
+// Section decodes the structured value of key (see TagRulesKey) into target
+// from the user config or, if it has none, the system config. Within a file
+// the selected profile overrides the top-level value; values are not merged.
+// ok is false when no file sets key.
+func Section(key, profile string, target any) (origin Origin, ok bool, err error) {
+	for _, f := range []struct {
+		source Source
+		path   string
+	}{
+		{SourceUser, UserPath()},
+		{SourceSystem, systemPath},
+	} {
+		if f.path == "" {
+			continue
+		}
+		doc, err := readFile(f.path)
+		if err != nil {
+			return Origin{}, false, err
+		}
+		if doc == nil {
+			continue
+		}
+
+		origin = Origin{Source: f.source, Path: f.path}
+		v, found := doc[key]
+		if profiles, isMap := doc[profilesKey].(map[string]any); isMap && profile != "" {
+			if values, isMap := profiles[profile].(map[string]any); isMap {
+				if pv, inProfile := values[key]; inProfile {
+					v, found = pv, true
+					origin.Profile = profile
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return Origin{}, false, fmt.Errorf("%s: key %q: %w", f.path, key, err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(target); err != nil {
+			return Origin{}, false, fmt.Errorf("%s: key %q: %w", f.path, key, err)
+		}
+		return origin, true, nil
+	}
+	return Origin{}, false, nil
+}
+
+// readFile parses a config file. A missing file yields a nil document.
+func readFile(path string) (map[string]any, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// loadFile reads a config file. A missing file yields a nil layer.
+func loadFile(source Source, path string, known map[string]bool) (*layer, error) {
+	doc, err := readFile(path)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+
+	l := &layer{source: source, path: path, profiles: make(map[string]map[string]string)}
+
+	if rawProfiles, ok := doc[profilesKey]; ok {
+		profiles, ok := rawProfiles.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %q must be a map of profile name to settings", path, profilesKey)
+		}
+		for name, rawValues := range profiles {
+			values, ok := rawValues.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: profile %q must be a map", path, name)
+			}
+			if l.profiles[name], err = stringValues(path, values, known); err != nil {
+				return nil, err
+			}
+		}
+		delete(doc, profilesKey)
+	}
+
+	if l.values, err = stringValues(path, doc, known); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// stringValues converts YAML scalars (and lists, joined with commas) into flag strings.
+func stringValues(path string, in map[string]any, known map[string]bool) (map[string]string, error) {
+	out := make(map[string]string, len(in))
+	for key, v := range in {
+		if sectionKeys[key] {
+			continue
+		}
+		if !known[key] {
+			return nil, fmt.Errorf("%s: unknown key %q (known: %s)", path, key, strings.Join(sortedKeys(known), ", "))
+		}
+		s, err := scalarString(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", path, key, err)
+		}
+		out[key] = s
+	}
+	return out, nil
+}
+
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := scalarString(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
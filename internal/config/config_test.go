@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// testFiles writes the user and system config files, skipping empty ones,
+// and points UserPath and systemPath at them.
+func testFiles(t *testing.T, user, system string) (userPath, sysPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	userPath, sysPath = UserPath(), filepath.Join(dir, "etc", "config.yaml")
+
+	t.Cleanup(SetSystemPath(sysPath))
+
+	for path, content := range map[string]string{userPath: user, sysPath: system} {
+		if content == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return userPath, sysPath
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		system  string
+		env     string // DECKHOUSE_STATUS_TIMEOUT
+		args    []string
+		profile string
+
+		want        string // value of timeout
+		wantSource  Source
+		wantPath    string // "user", "system", "env" or empty
+		wantProfile string
+		wantErr     string // substring
+	}{
+		{name: "default", want: "15", wantSource: SourceDefault},
+		{name: "system config", system: "timeout: 20", want: "20", wantSource: SourceSystem, wantPath: "system"},
+		{name: "user over system", user: "timeout: 25", system: "timeout: 20", want: "25", wantSource: SourceUser, wantPath: "user"},
+		{name: "env over user", user: "timeout: 25", env: "30", want: "30", wantSource: SourceEnv, wantPath: "env"},
+		{name: "flag over env", env: "30", args: []string{"--timeout=35"}, want: "35", wantSource: SourceFlag},
+		{
+			name:    "profile over the top-level key of its file",
+			user:    "timeout: 25\nprofiles:\n  ci:\n    timeout: 40",
+			profile: "ci",
+			want:    "40", wantSource: SourceUser, wantPath: "user", wantProfile: "ci",
+		},
+		{
+			name:    "profile of the system config under the top-level user key",
+			user:    "timeout: 25",
+			system:  "profiles:\n  ci:\n    timeout: 40",
+			profile: "ci",
+			want:    "25", wantSource: SourceUser, wantPath: "user",
+		},
+		{
+			name:    "profile under env",
+			user:    "profiles:\n  ci:\n    timeout: 40",
+			env:     "30",
+			profile: "ci",
+			want:    "30", wantSource: SourceEnv, wantPath: "env",
+		},
+		{
+			name: "unselected profile",
+			user: "timeout: 25\nprofiles:\n  ci:\n    timeout: 40",
+			want: "25", wantSource: SourceUser, wantPath: "user",
+		},
+		{name: "missing profile", user: "timeout: 25", profile: "ci", wantErr: `profile "ci" not found`},
+		{name: "unknown key", user: "timout: 25", wantErr: `unknown key "timout"`},
+		{name: "invalid value", user: "timeout: soon", wantErr: `invalid value "soon" for timeout`},
+		{name: "tag rules are not a setting", user: "tag-rules: []", want: "15", wantSource: SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userPath, sysPath := testFiles(t, tt.user, tt.system)
+			if tt.env != "" {
+				t.Setenv(EnvName("timeout"), tt.env)
+			}
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.Int("timeout", 15, "")
+			fs.Bool("short", false, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			settings, err := Apply([]string{"timeout", "short"}, fs.Lookup, tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			paths := map[string]string{"user": userPath, "system": sysPath, "env": "DECKHOUSE_STATUS_TIMEOUT"}
+			wantOrigin := Origin{Source: tt.wantSource, Path: paths[tt.wantPath], Profile: tt.wantProfile}
+			want := []Setting{
+				{Key: "timeout", Value: tt.want, Origin: wantOrigin},
+				{Key: "short", Value: "false", Origin: Origin{Source: SourceDefault}},
+			}
+			if !reflect.DeepEqual(settings, want) {
+				t.Errorf("settings = %+v, want %+v", settings, want)
+			}
+		})
+	}
+}
+
+func TestApplyValues(t *testing.T) {
+	testFiles(t, "short: true\ncontexts: [dev, stage]\nratio: 0.5", "")
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.Bool("short", false, "")
+	fs.StringSlice("contexts", nil, "")
+	fs.Float64("ratio", 1, "")
+
+	settings, err := Apply([]string{"short", "contexts", "ratio"}, fs.Lookup, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range settings {
+		got = append(got, s.Key+"="+s.Value)
+	}
+	if want := []string{"short=true", "contexts=[dev,stage]", "ratio=0.5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("settings = %v, want %v", got, want)
+	}
+
+	testFiles(t, "", "")
+	if _, err := Apply([]string{"short", "missing"}, fs.Lookup, ""); err == nil || !strings.Contains(err.Error(), `config key "missing" has no flag`) {
+		t.Errorf("err = %v, want a key without a flag", err)
+	}
+}
+
+type testRule struct {
+	Name  string `json:"name"`
+	Match string `json:"match"`
+}
+
+func TestSection(t *testing.T) {
+	const (
+		userRules   = "tag-rules:\n  - name: user\n    match: ^u$\n"
+		systemRules = "tag-rules:\n  - name: system\n    match: ^s$\n"
+		profiles    = "profiles:\n  ci:\n    tag-rules:\n      - name: ci\n        match: ^ci$\n"
+	)
+
+	tests := []struct {
+		name    string
+		user    string
+		system  string
+		profile string
+
+		want        []testRule
+		wantSource  Source
+		wantProfile string
+		wantErr     string // substring
+	}{
+		{name: "not set"},
+		{name: "system config", system: systemRules, want: []testRule{{"system", "^s$"}}, wantSource: SourceSystem},
+		{name: "user config replaces the system one", user: userRules, system: systemRules, want: []testRule{{"user", "^u$"}}, wantSource: SourceUser},
+		{name: "user config without the key", user: "timeout: 20", system: systemRules, want: []testRule{{"system", "^s$"}}, wantSource: SourceSystem},
+		{
+			name: "profile overrides the top-level value", user: userRules + profiles, profile: "ci",
+			want: []testRule{{"ci", "^ci$"}}, wantSource: SourceUser, wantProfile: "ci",
+		},
+		{name: "unselected profile", user: userRules + profiles, want: []testRule{{"user", "^u$"}}, wantSource: SourceUser},
+		{name: "profile without the key", user: userRules, profile: "ci", want: []testRule{{"user", "^u$"}}, wantSource: SourceUser},
+		{name: "unknown field", user: "tag-rules:\n  - name: user\n    mtach: ^u$\n", wantErr: `unknown field "mtach"`},
+		{name: "wrong type", user: "tag-rules: {name: user}", wantErr: `key "tag-rules"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userPath, sysPath := testFiles(t, tt.user, tt.system)
+
+			var rules []testRule
+			origin, ok, err := Section(TagRulesKey, tt.profile, &rules)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != (tt.want != nil) || !reflect.DeepEqual(rules, tt.want) {
+				t.Fatalf("Section = %v, %+v; want %+v", ok, rules, tt.want)
+			}
+			if !ok {
+				return
+			}
+			wantOrigin := Origin{Source: tt.wantSource, Path: map[Source]string{SourceUser: userPath, SourceSystem: sysPath}[tt.wantSource], Profile: tt.wantProfile}
+			if origin != wantOrigin {
+				t.Errorf("origin = %+v, want %+v", origin, wantOrigin)
+			}
+		})
+	}
+}
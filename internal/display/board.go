@@ -0,0 +1,206 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+// BoardRow is one check on the live checks board.
+type BoardRow struct {
+	Name        string
+	Status      string // "queued", "in_progress", "completed"
+	Conclusion  string
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// Board renders a live multi-line list of checks to stderr, one spinner line
+// per check. Like Spinner it redraws asynchronously between API polls.
+type Board struct {
+	frames   []string
+	noEmoji  bool
+	started  time.Time
+	interval time.Duration
+
+	mu     sync.Mutex
+	frame  int
+	title  string
+	rows   []BoardRow
+	drawn  int // lines drawn by the previous render, to move the cursor back
+	done   bool
+	stopCh chan struct{}
+
+	// ANSI codes
+	reset, bold, dim, cyan, green, red string
+}
+
+// NewBoard creates a board respecting color/emoji preferences and starts the render loop.
+func NewBoard(noColor, noEmoji bool) *Board {
+	b := &Board{
+		noEmoji:  noEmoji,
+		started:  time.Now(),
+		interval: 100 * time.Millisecond,
+		stopCh:   make(chan struct{}),
+	}
+
+	if noEmoji {
+		b.frames = []string{"|", "/", "-", "\\"}
+	} else {
+		b.frames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	}
+
+	if !noColor {
+		b.reset = "\033[0m"
+		b.bold = "\033[1m"
+		b.dim = "\033[2m"
+		b.cyan = "\033[36m"
+		b.green = "\033[32m"
+		b.red = "\033[31m"
+	}
+
+	go b.loop()
+	return b
+}
+
+func (b *Board) loop() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			if !b.done {
+				b.render()
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// Update replaces the title line and the rows.
+func (b *Board) Update(title string, rows []BoardRow) {
+	b.mu.Lock()
+	b.title = title
+	b.rows = rows
+	b.mu.Unlock()
+}
+
+// Log prints a message above the board.
+func (b *Board) Log(message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+	fmt.Fprintf(os.Stderr, "%s%s%s\n", b.dim, message, b.reset)
+	if !b.done {
+		b.render()
+	}
+}
+
+// Stop draws the final state and stops the render loop.
+func (b *Board) Stop() {
+	b.mu.Lock()
+	if b.done {
+		b.mu.Unlock()
+		return
+	}
+	b.render()
+	b.done = true
+	b.drawn = 0
+	b.mu.Unlock()
+
+	close(b.stopCh)
+}
+
+// Success draws the final state and a success line with terminal bell.
+func (b *Board) Success(message string) {
+	b.Stop()
+	b.final(b.green, b.emoji("\u2705", "+"), message)
+}
+
+// Failure draws the final state and a failure line with terminal bell.
+func (b *Board) Failure(message string) {
+	b.Stop()
+	b.final(b.red, b.emoji("\u274c", "x"), message)
+}
+
+func (b *Board) final(color, icon, message string) {
+	elapsed := time.Since(b.started).Truncate(time.Second)
+	fmt.Fprintf(os.Stderr, "%s%s %s%s %s[%s]%s\a\n", color, icon, message, b.reset, b.dim, elapsed, b.reset)
+}
+
+// clear erases the previously drawn lines. Caller holds mu.
+func (b *Board) clear() {
+	if b.drawn > 0 {
+		fmt.Fprintf(os.Stderr, "\033[%dF\033[J", b.drawn)
+		b.drawn = 0
+	}
+}
+
+// render redraws the board in place. Caller holds mu.
+func (b *Board) render() {
+	if b.title == "" && len(b.rows) == 0 {
+		return
+	}
+
+	spin := b.frames[b.frame%len(b.frames)]
+	b.frame++
+
+	var out strings.Builder
+	elapsed := time.Since(b.started).Truncate(time.Second)
+	fmt.Fprintf(&out, "\033[K%s%s%s %s[%s]%s\n", b.bold, b.title, b.reset, b.dim, elapsed, b.reset)
+
+	nameWidth := 0
+	for _, r := range b.rows {
+		nameWidth = max(nameWidth, len(r.Name))
+	}
+	for _, r := range b.rows {
+		icon, state := b.rowState(r, spin)
+		fmt.Fprintf(&out, "\033[K  %s %-*s  %s %s%s%s\n", icon, nameWidth, r.Name, state, b.dim, rowDuration(r), b.reset)
+	}
+	out.WriteString("\033[J") // the board may have shrunk
+
+	if b.drawn > 0 {
+		fmt.Fprintf(os.Stderr, "\033[%dF", b.drawn)
+	}
+	fmt.Fprint(os.Stderr, out.String())
+	b.drawn = len(b.rows) + 1
+}
+
+func (b *Board) rowState(r BoardRow, spin string) (icon, state string) {
+	switch {
+	case r.Status != "completed" && r.Status != "queued":
+		return b.cyan + spin + b.reset, b.cyan + "running" + b.reset
+	case r.Status == "queued":
+		return b.emoji("⏳", "."), b.dim + "queued" + b.reset
+	case github.IsPassing(r.Conclusion):
+		return b.emoji("✅", "+"), b.green + r.Conclusion + b.reset
+	default:
+		return b.emoji("❌", "x"), b.red + r.Conclusion + b.reset
+	}
+}
+
+func (b *Board) emoji(emojiStr, fallback string) string {
+	if b.noEmoji {
+		return fallback
+	}
+	return emojiStr
+}
+
+func rowDuration(r BoardRow) string {
+	if r.StartedAt.IsZero() {
+		return ""
+	}
+	end := r.CompletedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return humanDuration(end.Sub(r.StartedAt))
+}
@@ -0,0 +1,88 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+// Limits for the failure report printed by watch-build.
+const (
+	maxFailureAnnotations  = 5
+	maxFailureSummaryLines = 5
+)
+
+// PrintFailure prints why a check-run failed to stderr: failed steps, error
+// annotations, a link to the job log and, when given, the tail of the log.
+func (p *Printer) PrintFailure(f *github.Failure, logTail []string) {
+	if len(f.FailedSteps) > 0 {
+		fmt.Fprintf(os.Stderr, "%sFailed steps:%s %s\n", p.bold, p.reset, strings.Join(f.FailedSteps, ", "))
+	}
+
+	annotations := errorAnnotations(f.Annotations)
+	switch {
+	case len(annotations) > 0:
+		fmt.Fprintf(os.Stderr, "%sErrors:%s\n", p.bold, p.reset)
+		for i, a := range annotations {
+			if i == maxFailureAnnotations {
+				fmt.Fprintf(os.Stderr, "  %s... and %d more%s\n", p.dim, len(annotations)-i, p.reset)
+				break
+			}
+			fmt.Fprintf(os.Stderr, "  %s%s%s %s\n", p.red, annotationLocation(a), p.reset, a.Message)
+		}
+	case f.Title != "" || f.Summary != "":
+		// Without annotations the check output is the best explanation there is.
+		if f.Title != "" {
+			fmt.Fprintf(os.Stderr, "%s%s%s\n", p.bold, f.Title, p.reset)
+		}
+		lines := strings.Split(f.Summary, "\n")
+		for i, line := range lines {
+			if i == maxFailureSummaryLines {
+				fmt.Fprintf(os.Stderr, "  %s...%s\n", p.dim, p.reset)
+				break
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(os.Stderr, "  %s\n", line)
+			}
+		}
+	}
+
+	if f.URL != "" {
+		fmt.Fprintf(os.Stderr, "%sLog:%s %s\n", p.bold, p.reset, f.URL)
+	}
+
+	if len(logTail) > 0 {
+		fmt.Fprintf(os.Stderr, "%sLast %d log lines:%s\n", p.bold, len(logTail), p.reset)
+		for _, line := range logTail {
+			fmt.Fprintf(os.Stderr, "  %s%s%s\n", p.dim, line, p.reset)
+		}
+	}
+}
+
+// errorAnnotations returns the failure-level annotations, or all of them when
+// the check reports none at that level.
+func errorAnnotations(all []github.Annotation) []github.Annotation {
+	var errs []github.Annotation
+	for _, a := range all {
+		if a.Level == "failure" {
+			errs = append(errs, a)
+		}
+	}
+	if len(errs) == 0 {
+		return all
+	}
+	return errs
+}
+
+func annotationLocation(a github.Annotation) string {
+	switch {
+	case a.Path == "":
+		return "-"
+	case a.Line > 0:
+		return fmt.Sprintf("%s:%d", a.Path, a.Line)
+	default:
+		return a.Path
+	}
+}
@@ -2,10 +2,11 @@ package display
 
 import (
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
 )
 
 func humanDuration(d time.Duration) string {
@@ -44,6 +45,32 @@ func parseTZ(tz string) *time.Location {
 	return time.FixedZone("MSK", 3*3600)
 }
 
+func pluralCommits(n int) string {
+	if n == 1 {
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", n)
+}
+
+// cachedAgo describes the age of cached GitHub data: "cached 12m ago".
+func cachedAgo(t time.Time) string {
+	return "cached " + humanDuration(time.Since(t)) + " ago"
+}
+
+// checksSummary counts the completed check-runs and names the failed ones.
+func checksSummary(runs []github.CheckRun) (completed int, failed []string) {
+	for _, cr := range runs {
+		if cr.Status != "completed" {
+			continue
+		}
+		completed++
+		if !github.IsPassing(cr.Conclusion) {
+			failed = append(failed, cr.Name)
+		}
+	}
+	return completed, failed
+}
+
 func abs(n int) int {
 	if n < 0 {
 		return -n
@@ -51,21 +78,37 @@ func abs(n int) int {
 	return n
 }
 
-// ParsePRTag extracts PR number and edition from an image tag.
-// "pr15160" → (15160, "FE")
-// "pr15160-ce" → (15160, "CE")
-// "main" → (0, "")
-var prTagRe = regexp.MustCompile(`^pr(\d+)(?:-(.+))?$`)
+// imageName strips the registry and repository path from an image reference:
+// "registry.example.com/deckhouse/kube-rbac-proxy:v0.1" → "kube-rbac-proxy:v0.1".
+func imageName(image string) string {
+	if i := strings.LastIndex(image, "/"); i != -1 {
+		return image[i+1:]
+	}
+	return image
+}
+
+// shortDigest abbreviates "sha256:3778e43a..." to "sha256:3778e43a1b2c"; empty
+// digests (image not pulled yet) become "-".
+func shortDigest(digest string) string {
+	if digest == "" {
+		return "-"
+	}
+	if algo, hex, ok := strings.Cut(digest, ":"); ok && len(hex) > 12 {
+		return algo + ":" + hex[:12]
+	}
+	return digest
+}
 
-func ParsePRTag(tag string) (int, string) {
-	matches := prTagRe.FindStringSubmatch(tag)
-	if len(matches) < 2 {
-		return 0, ""
+// truncate shortens s to its first line of at most n runes, marking a cut
+// with "...".
+func truncate(s string, n int) string {
+	line, _, multiline := strings.Cut(s, "\n")
+	r := []rune(line)
+	if len(r) > n {
+		return string(r[:n-3]) + "..."
 	}
-	n, _ := strconv.Atoi(matches[1])
-	edition := "FE"
-	if len(matches) > 2 && matches[2] != "" {
-		edition = strings.ToUpper(matches[2])
+	if multiline {
+		return line + "..."
 	}
-	return n, edition
+	return line
 }
@@ -0,0 +1,438 @@
+package display
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/imagetag"
+)
+
+// JSONSchemaVersion is the version of the document written by --output json.
+// Bump it on any incompatible change (renamed or removed fields, changed types).
+const JSONSchemaVersion = 1
+
+// JSON document layout. These types are deliberately separate from the
+// kube/github/registry structs so the output stays stable when those change
+// and so secrets (registry credentials) can never leak into it.
+
+type jsonDocument struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	GeneratedAt   time.Time      `json:"generatedAt"`
+	Cluster       *jsonCluster   `json:"cluster"`
+	PR            *jsonPR        `json:"pr,omitempty"`
+	Branch        *jsonBranch    `json:"branch,omitempty"`
+	Release       *jsonRelease   `json:"release,omitempty"`
+	Registry      *jsonRegistry  `json:"registry,omitempty"`
+	Deckhouse     *jsonDeckhouse `json:"deckhouse,omitempty"`
+	Verdict       jsonVerdict    `json:"verdict"`
+}
+
+type jsonCluster struct {
+	Image         string      `json:"image"`
+	Registry      string      `json:"registry"`
+	Repository    string      `json:"repository"`
+	Tag           string      `json:"tag"`
+	TagKind       string      `json:"tagKind"` // "pr", "branch", "release" or "unknown"
+	PodName       string      `json:"podName"`
+	PodCreated    time.Time   `json:"podCreated"`
+	PodPhase      string      `json:"podPhase"`
+	RunningDigest string      `json:"runningDigest,omitempty"`
+	DeployedSHA   string      `json:"deployedSha,omitempty"`
+	Pods          []jsonPod   `json:"pods"`             // every replica; the fields above describe the primary one
+	Events        []jsonEvent `json:"events,omitempty"` // Warning events of the primary pod, newest first
+}
+
+type jsonPod struct {
+	Name       string          `json:"name"`
+	Node       string          `json:"node,omitempty"`
+	Phase      string          `json:"phase"`
+	Created    time.Time       `json:"created"`
+	Ready      bool            `json:"ready"`
+	Containers []jsonContainer `json:"containers"`
+}
+
+type jsonContainer struct {
+	Name     string `json:"name"`
+	Init     bool   `json:"init,omitempty"`
+	Main     bool   `json:"main,omitempty"` // the deckhouse container
+	Image    string `json:"image"`
+	Digest   string `json:"digest,omitempty"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	// Waiting is the reason the container is not running, e.g. "CrashLoopBackOff".
+	Waiting         string           `json:"waiting,omitempty"`
+	WaitingMessage  string           `json:"waitingMessage,omitempty"`
+	LastTermination *jsonTermination `json:"lastTermination,omitempty"`
+}
+
+type jsonTermination struct {
+	Reason     string    `json:"reason"`
+	ExitCode   int32     `json:"exitCode"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+type jsonEvent struct {
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+type jsonPR struct {
+	Repo       string       `json:"repo"`
+	Number     int          `json:"number"`
+	Edition    string       `json:"edition"`
+	Title      string       `json:"title,omitempty"`
+	URL        string       `json:"url,omitempty"`
+	HeadSHA    string       `json:"headSha,omitempty"`
+	UpdatedAt  *time.Time   `json:"updatedAt,omitempty"`
+	LastCommit *jsonCommit  `json:"lastCommit,omitempty"`
+	Build      *jsonBuild   `json:"build,omitempty"`
+	Checks     *jsonChecks  `json:"checks,omitempty"`   // all checks on the head; only with a GitHub token
+	Pending    *jsonCompare `json:"pending,omitempty"`  // commits not deployed yet
+	CachedAt   *time.Time   `json:"cachedAt,omitempty"` // set when GitHub was unreachable and cached data was used
+	Error      *jsonError   `json:"error,omitempty"`
+}
+
+type jsonBranch struct {
+	Repo       string       `json:"repo"`
+	Branch     string       `json:"branch"`
+	Edition    string       `json:"edition"`
+	URL        string       `json:"url,omitempty"`
+	HeadSHA    string       `json:"headSha,omitempty"`
+	LastCommit *jsonCommit  `json:"lastCommit,omitempty"`
+	Build      *jsonBuild   `json:"build,omitempty"`
+	Pending    *jsonCompare `json:"pending,omitempty"`
+	CachedAt   *time.Time   `json:"cachedAt,omitempty"`
+	Error      *jsonError   `json:"error,omitempty"`
+}
+
+type jsonRelease struct {
+	Repo           string     `json:"repo"`
+	Tag            string     `json:"tag"`
+	Edition        string     `json:"edition"`
+	URL            string     `json:"url,omitempty"`
+	PublishedAt    *time.Time `json:"publishedAt,omitempty"`
+	LatestPatch    string     `json:"latestPatch,omitempty"`
+	LatestPatchURL string     `json:"latestPatchUrl,omitempty"`
+	CachedAt       *time.Time `json:"cachedAt,omitempty"`
+	Error          *jsonError `json:"error,omitempty"`
+}
+
+type jsonCompare struct {
+	CommitsBehind int               `json:"commitsBehind"`
+	Commits       []jsonPendingItem `json:"commits"`
+	Error         *jsonError        `json:"error,omitempty"`
+}
+
+type jsonPendingItem struct {
+	SHA     string     `json:"sha"`
+	Author  string     `json:"author"`
+	Message string     `json:"message"`
+	Date    *time.Time `json:"date,omitempty"`
+}
+
+type jsonCommit struct {
+	Author  string     `json:"author"`
+	Date    *time.Time `json:"date,omitempty"`
+	Message string     `json:"message"`
+}
+
+type jsonBuild struct {
+	CheckName   string     `json:"checkName"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+type jsonChecks struct {
+	State     string   `json:"state"` // "success", "failure", "error", "pending", "expected"
+	Total     int      `json:"total"`
+	Completed int      `json:"completed"`
+	Failed    []string `json:"failed"`
+}
+
+type jsonRegistry struct {
+	TagExists   bool       `json:"tagExists"`
+	Digest      string     `json:"digest,omitempty"`
+	MediaType   string     `json:"mediaType,omitempty"`
+	DigestMatch bool       `json:"digestMatch"`
+	Platform    string     `json:"platform,omitempty"`
+	ImageExists bool       `json:"imageExists"`
+	Revision    string     `json:"revision,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	Error       *jsonError `json:"error,omitempty"`
+}
+
+type jsonVerdict struct {
+	State  string `json:"state"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+	Source string `json:"source"` // evidence: "registry", "build", "commit", "release", "cluster" or "none"
+	Action string `json:"action,omitempty"`
+	// Health is a problem of the primary pod, e.g. "CrashLoopBackOff".
+	Health       string `json:"health,omitempty"`
+	HealthReason string `json:"healthReason,omitempty"`
+}
+
+type jsonDeckhouse struct {
+	MainQueue    int                `json:"mainQueue"`
+	Converge     string             `json:"converge,omitempty"` // "startup", "in_progress" or "done"
+	ModuleErrors []jsonModuleErrors `json:"moduleErrors,omitempty"`
+	Error        *jsonError         `json:"error,omitempty"`
+}
+
+type jsonModuleErrors struct {
+	Module string `json:"module"`
+	Errors int    `json:"errors"` // hook errors since the controller started
+}
+
+type jsonError struct {
+	Source  string `json:"source"` // "github", "registry" or "controller"
+	Message string `json:"message"`
+}
+
+func (p *Printer) renderJSON(d RenderData) error {
+	doc := jsonDocument{
+		SchemaVersion: JSONSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Cluster:       newJSONCluster(d),
+		PR:            newJSONPR(d),
+		Branch:        newJSONBranch(d),
+		Release:       newJSONRelease(d),
+		Registry:      newJSONRegistry(d),
+		Deckhouse:     newJSONDeckhouse(d),
+	}
+
+	v := p.evaluate(d)
+	doc.Verdict = jsonVerdict{
+		State:  v.Kind.String(),
+		Title:  v.Kind.Title(),
+		Reason: v.Reason,
+		Source: string(v.Source),
+		Action: string(v.Action),
+
+		Health:       v.Health,
+		HealthReason: v.HealthReason,
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func newJSONCluster(d RenderData) *jsonCluster {
+	c := d.Cluster
+	pods := make([]jsonPod, 0, len(c.Pods))
+	for _, p := range c.Pods {
+		containers := make([]jsonContainer, 0, len(p.Containers))
+		for _, ctr := range p.Containers {
+			jc := jsonContainer{
+				Name:     ctr.Name,
+				Init:     ctr.Init,
+				Main:     ctr.Main,
+				Image:    ctr.Image,
+				Digest:   ctr.Digest,
+				Ready:    ctr.Ready,
+				Restarts: ctr.Restarts,
+
+				Waiting:        ctr.Waiting,
+				WaitingMessage: ctr.WaitingMessage,
+			}
+			if t := ctr.LastTermination; t != nil {
+				jc.LastTermination = &jsonTermination{Reason: t.Reason, ExitCode: t.ExitCode, FinishedAt: t.FinishedAt.UTC()}
+			}
+			containers = append(containers, jc)
+		}
+		pods = append(pods, jsonPod{Name: p.Name, Node: p.Node, Phase: p.Phase, Created: p.Created.UTC(), Ready: p.Ready, Containers: containers})
+	}
+	var events []jsonEvent
+	for _, e := range c.Events {
+		events = append(events, jsonEvent{Reason: e.Reason, Message: e.Message, Count: e.Count, LastSeen: e.LastSeen.UTC()})
+	}
+	return &jsonCluster{
+		Image:         c.Image,
+		Registry:      c.Registry,
+		Repository:    c.Repository,
+		Tag:           c.Tag,
+		TagKind:       d.Tag.Kind.String(),
+		PodName:       c.PodName,
+		PodCreated:    c.PodCreated.UTC(),
+		PodPhase:      c.PodPhase,
+		RunningDigest: c.RunningDigest,
+		DeployedSHA:   d.DeployedSHA,
+		Pods:          pods,
+		Events:        events,
+	}
+}
+
+func newJSONDeckhouse(d RenderData) *jsonDeckhouse {
+	switch {
+	case d.ControllerErr != nil:
+		return &jsonDeckhouse{Error: &jsonError{Source: "controller", Message: d.ControllerErr.Error()}}
+	case d.Controller == nil:
+		return nil
+	}
+	out := &jsonDeckhouse{
+		MainQueue:    d.Controller.MainQueue,
+		Converge:     d.Controller.Converge,
+		ModuleErrors: make([]jsonModuleErrors, 0, len(d.Controller.ModuleErrors)),
+	}
+	for _, m := range d.Controller.ModuleErrors {
+		out.ModuleErrors = append(out.ModuleErrors, jsonModuleErrors{Module: m.Module, Errors: m.Errors})
+	}
+	return out
+}
+
+func newJSONPR(d RenderData) *jsonPR {
+	if d.Tag.Kind != imagetag.PR {
+		return nil
+	}
+
+	out := &jsonPR{Repo: d.Repo, Number: d.Tag.PRNumber, Edition: d.Tag.Edition}
+	if d.GitHubErr != nil {
+		out.Error = &jsonError{Source: "github", Message: d.GitHubErr.Error()}
+		return out
+	}
+	pr := d.PR
+	if pr == nil {
+		return out
+	}
+
+	out.Title = pr.Title
+	out.URL = pr.URL
+	out.HeadSHA = pr.HeadSHA
+	out.UpdatedAt = optionalTime(pr.UpdatedAt)
+	out.LastCommit = newJSONCommit(&pr.HeadBuild)
+	out.Build = newJSONBuild(&pr.HeadBuild)
+	out.Checks = newJSONChecks(pr)
+	out.Pending = newJSONCompare(d)
+	out.CachedAt = optionalTime(pr.CachedAt)
+	return out
+}
+
+func newJSONBranch(d RenderData) *jsonBranch {
+	if d.Tag.Kind != imagetag.Branch {
+		return nil
+	}
+
+	out := &jsonBranch{Repo: d.Repo, Branch: d.Tag.Branch, Edition: d.Tag.Edition}
+	if d.GitHubErr != nil {
+		out.Error = &jsonError{Source: "github", Message: d.GitHubErr.Error()}
+		return out
+	}
+	br := d.Branch
+	if br == nil {
+		return out
+	}
+
+	out.URL = br.URL
+	out.HeadSHA = br.HeadSHA
+	out.LastCommit = newJSONCommit(&br.HeadBuild)
+	out.Build = newJSONBuild(&br.HeadBuild)
+	out.Pending = newJSONCompare(d)
+	out.CachedAt = optionalTime(br.CachedAt)
+	return out
+}
+
+func newJSONRelease(d RenderData) *jsonRelease {
+	if d.Tag.Kind != imagetag.Release {
+		return nil
+	}
+
+	out := &jsonRelease{Repo: d.Repo, Tag: d.Tag.Version, Edition: d.Tag.Edition}
+	if d.GitHubErr != nil {
+		out.Error = &jsonError{Source: "github", Message: d.GitHubErr.Error()}
+		return out
+	}
+	r := d.Release
+	if r == nil {
+		return out
+	}
+
+	out.URL = r.URL
+	out.PublishedAt = optionalTime(r.PublishedAt)
+	out.LatestPatch = r.LatestPatch
+	out.LatestPatchURL = r.LatestPatchURL
+	out.CachedAt = optionalTime(r.CachedAt)
+	return out
+}
+
+func newJSONCommit(h *github.HeadBuild) *jsonCommit {
+	if h.CommitAuthor == "" && h.CommitMessage == "" {
+		return nil
+	}
+	return &jsonCommit{
+		Author:  h.CommitAuthor,
+		Date:    optionalTime(h.CommitDate),
+		Message: h.CommitMessage,
+	}
+}
+
+func newJSONBuild(h *github.HeadBuild) *jsonBuild {
+	return &jsonBuild{
+		CheckName:   h.BuildCheckName,
+		Status:      h.BuildStatus,
+		Conclusion:  h.BuildConclusion,
+		CompletedAt: optionalTime(h.BuildCompletedAt),
+	}
+}
+
+func newJSONChecks(pr *github.PRInfo) *jsonChecks {
+	if pr.ChecksState == "" {
+		return nil
+	}
+	completed, failed := checksSummary(pr.Checks)
+	if failed == nil {
+		failed = []string{}
+	}
+	return &jsonChecks{State: pr.ChecksState, Total: len(pr.Checks), Completed: completed, Failed: failed}
+}
+
+func newJSONCompare(d RenderData) *jsonCompare {
+	if d.CompareErr != nil {
+		return &jsonCompare{Commits: []jsonPendingItem{}, Error: &jsonError{Source: "github", Message: d.CompareErr.Error()}}
+	}
+	if d.Compare == nil {
+		return nil
+	}
+
+	out := &jsonCompare{CommitsBehind: d.Compare.AheadBy, Commits: make([]jsonPendingItem, 0, len(d.Compare.Commits))}
+	for _, c := range d.Compare.Commits {
+		out.Commits = append(out.Commits, jsonPendingItem{SHA: c.SHA, Author: c.Author, Message: c.Message, Date: optionalTime(c.Date)})
+	}
+	return out
+}
+
+func newJSONRegistry(d RenderData) *jsonRegistry {
+	reg := d.Registry
+	if reg == nil {
+		return nil
+	}
+
+	out := &jsonRegistry{
+		TagExists:   reg.TagExists,
+		Digest:      reg.Digest,
+		MediaType:   reg.MediaType,
+		DigestMatch: reg.DigestMatch,
+		Platform:    reg.Platform,
+		ImageExists: reg.ImageExists,
+		Revision:    reg.Revision,
+		Created:     optionalTime(reg.Created),
+	}
+	if reg.Err != nil {
+		out.Error = &jsonError{Source: "registry", Message: reg.Err.Error()}
+	}
+	return out
+}
+
+// optionalTime returns nil for the zero time so it is omitted from the document.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -6,8 +6,10 @@ import (
 	"time"
 
 	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/imagetag"
 	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
 	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+	"github.com/glitchy-sheep/deckhouse-status/internal/verdict"
 )
 
 // Config controls what to display and how.
@@ -19,16 +21,72 @@ type Config struct {
 	NoEmoji    bool
 	Timeout    int
 	TZ         string
+	Output     string // OutputText or OutputJSON
+	Repo       string // GitHub "owner/name"; empty means auto-detect
+	Verbose    bool   // print diagnostics (GitHub quota, ...) to stderr
 }
 
+// Output formats accepted by Config.Output.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
 // RenderData holds all collected data for rendering.
 type RenderData struct {
-	Cluster  *kube.ClusterInfo
-	PRNumber int
-	Edition  string
-	PR       *github.PRInfo
-	PRErr    error
+	Cluster *kube.ClusterInfo
+	Tag     imagetag.Tag
+	Repo    string // GitHub "owner/name" the tag's source is looked up in
+
+	// At most one of PR, Branch and Release is set, by Tag.Kind.
+	PR        *github.PRInfo
+	Branch    *github.BranchInfo
+	Release   *github.ReleaseInfo
+	GitHubErr error // the PR, branch or release lookup failed
+
 	Registry *registry.Result
+	// DeployedSHA is the source commit of the running image, empty if unknown.
+	DeployedSHA string
+	// Compare lists PR or branch commits not deployed yet; nil when not fetched.
+	Compare    *github.Comparison
+	CompareErr error
+
+	// Controller is the deckhouse-controller queue and converge status; nil
+	// when not fetched.
+	Controller    *kube.ControllerStatus
+	ControllerErr error
+}
+
+// Head returns the head commit and build of the PR or branch, nil for other tags.
+func (d RenderData) Head() *github.HeadBuild {
+	switch {
+	case d.PR != nil:
+		return &d.PR.HeadBuild
+	case d.Branch != nil:
+		return &d.Branch.HeadBuild
+	}
+	return nil
+}
+
+// headName is how the head is referred to: "PR head" or the branch name.
+func (d RenderData) headName() string {
+	if d.Tag.Kind == imagetag.Branch {
+		return d.Tag.Branch
+	}
+	return "PR head"
+}
+
+// VerdictInput returns the input of verdict.Evaluate for d.
+func (d RenderData) VerdictInput() verdict.Input {
+	return verdict.Input{
+		Cluster:     d.Cluster,
+		Head:        d.Head(),
+		Release:     d.Release,
+		Registry:    d.Registry,
+		DeployedSHA: d.DeployedSHA,
+		Compare:     d.Compare,
+		HeadName:    d.headName(),
+	}
 }
 
 // Printer handles formatted output with configurable colors and emojis.
@@ -58,26 +116,35 @@ func NewPrinter(cfg Config) *Printer {
 	return p
 }
 
-// Render prints the full or short status output.
-func (p *Printer) Render(d RenderData) {
-	if p.cfg.Short {
+// Render prints the full, short or JSON status output.
+func (p *Printer) Render(d RenderData) error {
+	switch {
+	case p.cfg.Output == OutputJSON:
+		return p.renderJSON(d)
+	case p.cfg.Short:
 		p.renderShort(d)
-		return
+	default:
+		p.renderFull(d)
 	}
-	p.renderFull(d)
+	return nil
 }
 
 func (p *Printer) renderFull(d RenderData) {
 	p.printHeader()
 	p.printCluster(d.Cluster)
-	if d.PRNumber > 0 && !p.cfg.NoGitHub {
-		p.printGitHub(d.PR, d.PRErr)
+	p.printPods(d.Cluster)
+	if d.Controller != nil || d.ControllerErr != nil {
+		p.printController(d.Controller, d.ControllerErr)
+	}
+	if d.Tag.Kind != imagetag.Unknown && !p.cfg.NoGitHub {
+		p.printGitHub(d)
+		p.printPendingCommits(d.Compare, d.CompareErr)
 	}
-	p.printStatus(d.Cluster, d.PR, d.Registry, d.Edition)
+	p.printStatus(p.evaluate(d), d.Registry)
 }
 
 func (p *Printer) renderShort(d RenderData) {
-	status := p.statusLine(d.Cluster, d.PR, d.Registry, d.Edition)
+	status := p.statusLine(p.evaluate(d))
 
 	// Line 1: image tag + pod age + status
 	age := humanDuration(time.Since(d.Cluster.PodCreated))
@@ -90,12 +157,47 @@ func (p *Printer) renderShort(d RenderData) {
 		status,
 	)
 
-	// Line 2: PR info (if available)
-	if d.PR != nil {
-		fmt.Printf("   %s #%d — %s\n", p.emoji("📝", "PR"), d.PR.Number, d.PR.Title)
+	// Line 2: PR, branch or release info (if available)
+	behind := d.Compare != nil && d.Compare.AheadBy > 0
+	switch {
+	case d.PR != nil:
+		fmt.Printf("   %s #%d — %s%s\n", p.emoji("📝", "PR"), d.PR.Number, d.PR.Title, p.cachedSuffix(d.PR.CachedAt))
+	case d.Branch != nil:
+		state := ""
+		if behind {
+			state = fmt.Sprintf(" %sis %s behind%s", p.yellow, pluralCommits(d.Compare.AheadBy), p.reset)
+			behind = false // said here already
+		}
+		fmt.Printf("   %s %s%s%s\n", p.emoji("🌿", "BR"), d.Branch.Branch, state, p.cachedSuffix(d.Branch.CachedAt))
+	case d.Release != nil:
+		state := fmt.Sprintf(" %s(latest patch)%s", p.dim, p.reset)
+		if d.Release.LatestPatch != d.Release.Tag {
+			state = fmt.Sprintf("%s, latest patch is %s%s", p.yellow, d.Release.LatestPatch, p.reset)
+		}
+		fmt.Printf("   %s %s%s%s\n", p.emoji("🏷️", "REL"), d.Release.Tag, state, p.cachedSuffix(d.Release.CachedAt))
+	}
+
+	// Line 3: undeployed commits, collapsed to a count
+	if behind {
+		fmt.Printf("   %s %s%s behind%s\n", p.emoji("📥", "<-"), p.yellow, pluralCommits(d.Compare.AheadBy), p.reset)
 	}
 }
 
+// cachedSuffix marks data served from the cache: " (cached 12m ago)".
+func (p *Printer) cachedSuffix(cachedAt time.Time) string {
+	if cachedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(" %s(%s)%s", p.dim, cachedAgo(cachedAt), p.reset)
+}
+
+// evaluate computes the verdict for d, formatting times in the printer's timezone.
+func (p *Printer) evaluate(d RenderData) verdict.Verdict {
+	in := d.VerdictInput()
+	in.Location = p.loc
+	return verdict.Evaluate(in)
+}
+
 // --- Output helpers ---
 
 func (p *Printer) emoji(emojiStr, fallback string) string {
@@ -3,11 +3,14 @@ package display
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/glitchy-sheep/deckhouse-status/internal/github"
 	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
 	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+	"github.com/glitchy-sheep/deckhouse-status/internal/verdict"
 )
 
 func (p *Printer) printHeader() {
@@ -33,108 +36,311 @@ func (p *Printer) printCluster(c *kube.ClusterInfo) {
 	p.section(p.emoji("☸️ ", "[K8S]") + " CLUSTER")
 	p.row(p.emoji("🏷️", "*"), "Image", p.bold+c.Tag+p.reset)
 	p.row(p.emoji("🔄", ">"), "Updated", c.PodCreated.In(p.loc).Format("2006-01-02 15:04"))
-	p.row(p.emoji("⏱️", "T"), "Pod age", fmt.Sprintf("%s %s(%s)%s", humanDuration(time.Since(c.PodCreated)), p.dim, c.PodPhase, p.reset))
+	state := p.dim + "(" + c.PodPhase
+	if pod := c.Primary(); pod != nil && !pod.Ready {
+		state += ", " + p.reset + p.yellow + "not ready" + p.reset + p.dim
+	}
+	p.row(p.emoji("⏱️", "T"), "Pod age", fmt.Sprintf("%s %s)%s", humanDuration(time.Since(c.PodCreated)), state, p.reset))
 	p.row(p.emoji("📦", "P"), "Pod", p.dim+c.PodName+p.reset)
+	p.printPodHealth(c)
 	fmt.Println()
 }
 
-func (p *Printer) printGitHub(pr *github.PRInfo, prErr error) {
-	p.section(p.emoji("🐙", "[GH]") + " GITHUB")
+// maxEventMessage truncates event messages in the CLUSTER section.
+const maxEventMessage = 100
+
+// printPodHealth prints the waiting reasons, restarts and Warning events of
+// the primary pod; nothing for a healthy pod.
+func (p *Printer) printPodHealth(c *kube.ClusterInfo) {
+	if pod := c.Primary(); pod != nil {
+		// The deckhouse container first.
+		ctrs := slices.Clone(pod.Containers)
+		slices.SortStableFunc(ctrs, func(a, b kube.ContainerInfo) int {
+			switch {
+			case a.Main == b.Main:
+				return 0
+			case a.Main:
+				return -1
+			default:
+				return 1
+			}
+		})
+		for _, ctr := range ctrs {
+			if ctr.Waiting != "" {
+				detail := ctr.Name
+				if ctr.WaitingMessage != "" {
+					detail += ": " + truncate(ctr.WaitingMessage, maxEventMessage)
+				}
+				p.row(p.emoji("🚫", "!"), "Waiting", fmt.Sprintf("%s%s%s %s(%s)%s", p.red, ctr.Waiting, p.reset, p.dim, detail, p.reset))
+			}
+			if ctr.Restarts > 0 {
+				detail := ctr.Name
+				if t := ctr.LastTermination; t != nil {
+					detail += fmt.Sprintf(", last: %s, exit code %d, %s ago", t.Reason, t.ExitCode, humanDuration(time.Since(t.FinishedAt)))
+				}
+				p.row(p.emoji("🔁", "R"), "Restarts", fmt.Sprintf("%s%d%s %s(%s)%s", p.yellow, ctr.Restarts, p.reset, p.dim, detail, p.reset))
+			}
+		}
+	}
+
+	if len(c.Events) == 0 {
+		return
+	}
+	p.row(p.emoji("⚡", "E"), "Warning events", fmt.Sprint(len(c.Events)))
+	for _, e := range c.Events {
+		count := ""
+		if e.Count > 1 {
+			count = fmt.Sprintf(" ×%d", e.Count)
+		}
+		fmt.Printf("     %s%-12s%s %s%s%s%s  %s\n", p.dim, humanDuration(time.Since(e.LastSeen))+" ago", p.reset,
+			p.yellow, e.Reason, p.reset, count, truncate(e.Message, maxEventMessage))
+	}
+}
 
-	if prErr != nil {
-		p.row(p.emoji("⚠️", "!"), "Error", p.red+prErr.Error()+p.reset)
+// printPods lists every replica with its containers. The deckhouse container
+// shows the image digest; sidecars and init containers their image.
+func (p *Printer) printPods(c *kube.ClusterInfo) {
+	p.section(p.emoji("📦", "[PODS]") + " PODS")
+
+	for i := range c.Pods {
+		pod := &c.Pods[i]
+		ready := p.green + "ready" + p.reset
+		if !pod.Ready {
+			ready = p.yellow + "not ready" + p.reset
+		}
+		primary := ""
+		if pod.Name == c.PodName && len(c.Pods) > 1 {
+			primary = p.dim + " (primary)" + p.reset
+		}
+		node := pod.Node
+		if node == "" {
+			node = "-"
+		}
+		fmt.Printf("   %s%s%s  %s  %s, %s, %s%s\n", p.bold, pod.Name, p.reset, node, pod.Phase, ready,
+			humanDuration(time.Since(pod.Created)), primary)
+
+		for _, ctr := range pod.Containers {
+			name := ctr.Name
+			if ctr.Init {
+				name = "init:" + name
+			}
+			image := imageName(ctr.Image)
+			if ctr.Main {
+				image = shortDigest(ctr.Digest)
+			}
+			state := ""
+			switch {
+			case ctr.Init:
+			case ctr.Ready:
+				state = p.green + "ready" + p.reset
+			default:
+				state = p.yellow + "not ready" + p.reset
+			}
+			if ctr.Restarts > 0 {
+				state += fmt.Sprintf(" %s%d restarts%s", p.yellow, ctr.Restarts, p.reset)
+			}
+			fmt.Printf("     %-28s %s%s%s  %s\n", name, p.dim, image, p.reset, state)
+		}
+	}
+	fmt.Println()
+}
+
+// maxModuleErrors limits the modules listed in the DECKHOUSE section.
+const maxModuleErrors = 5
+
+// printController shows the deckhouse-controller queue and converge state.
+func (p *Printer) printController(st *kube.ControllerStatus, err error) {
+	p.section(p.emoji("🧭", "[D8]") + " DECKHOUSE")
+	if err != nil {
+		p.row(p.emoji("❌", "x"), "Controller", fmt.Sprintf("%sunavailable (%s)%s", p.dim, err, p.reset))
 		fmt.Println()
 		return
 	}
-	if pr == nil {
-		p.row(p.emoji("⚠️", "!"), "Error", p.red+"no data"+p.reset)
+
+	queue := p.green + "empty" + p.reset
+	if st.MainQueue > 0 {
+		queue = fmt.Sprintf("%s%d tasks%s", p.yellow, st.MainQueue, p.reset)
+	}
+	p.row(p.emoji("📋", "Q"), "Main queue", queue)
+
+	var converge string
+	switch st.Converge {
+	case kube.ConvergeStartup:
+		converge = p.yellow + "in progress" + p.reset + p.dim + " (first converge after start)" + p.reset
+	case kube.ConvergeInProgress:
+		converge = p.yellow + "in progress" + p.reset
+	default:
+		converge = p.green + "done" + p.reset
+	}
+	p.row(p.emoji("🔄", ">"), "Converge", converge)
+
+	if len(st.ModuleErrors) > 0 {
+		var parts []string
+		for i, m := range st.ModuleErrors {
+			if i == maxModuleErrors {
+				parts = append(parts, fmt.Sprintf("+%d more", len(st.ModuleErrors)-i))
+				break
+			}
+			parts = append(parts, m.String())
+		}
+		p.row(p.emoji("🧩", "!"), "Module errors", p.red+strings.Join(parts, ", ")+p.reset+p.dim+" since start"+p.reset)
+	}
+	fmt.Println()
+}
+
+func (p *Printer) printGitHub(d RenderData) {
+	p.section(p.emoji("🐙", "[GH]") + " GITHUB")
+
+	if d.GitHubErr != nil {
+		p.row(p.emoji("⚠️", "!"), "Error", p.red+d.GitHubErr.Error()+p.reset)
 		fmt.Println()
 		return
 	}
 
-	p.row(p.emoji("📝", "#"), "PR", fmt.Sprintf("%s#%d%s — %s", p.bold, pr.Number, p.reset, pr.Title))
-	p.row(p.emoji("🔗", "~"), "URL", p.dim+pr.URL+p.reset)
+	switch {
+	case d.PR != nil:
+		pr := d.PR
+		p.row(p.emoji("📝", "#"), "PR", fmt.Sprintf("%s#%d%s — %s", p.bold, pr.Number, p.reset, pr.Title))
+		p.row(p.emoji("🔗", "~"), "URL", p.dim+pr.URL+p.reset)
+		p.printHead(&pr.HeadBuild, "PR head", d.DeployedSHA)
+		p.printChecks(pr)
+	case d.Branch != nil:
+		br := d.Branch
+		p.row(p.emoji("🌿", "#"), "Branch", p.bold+br.Branch+p.reset)
+		p.row(p.emoji("🔗", "~"), "URL", p.dim+br.URL+p.reset)
+		p.printHead(&br.HeadBuild, br.Branch+" head", d.DeployedSHA)
+	case d.Release != nil:
+		p.printRelease(d.Release)
+	default:
+		p.row(p.emoji("⚠️", "!"), "Error", p.red+"no data"+p.reset)
+	}
+	fmt.Println()
+}
+
+// printHead prints the head commit of a PR or branch and how the deployed
+// commit relates to it.
+func (p *Printer) printHead(h *github.HeadBuild, headLabel, deployedSHA string) {
+	if !h.CachedAt.IsZero() {
+		p.row(p.emoji("🗄️", "C"), "Cached", p.yellow+cachedAgo(h.CachedAt)+p.reset+p.dim+" (GitHub unreachable)"+p.reset)
+	}
 
-	if pr.CommitAuthor != "" {
+	if h.CommitAuthor != "" {
 		dateStr := ""
-		if !pr.CommitDate.IsZero() {
-			dateStr = fmt.Sprintf(" %s(%s)%s", p.dim, pr.CommitDate.In(p.loc).Format("2006-01-02"), p.reset)
+		if !h.CommitDate.IsZero() {
+			dateStr = fmt.Sprintf(" %s(%s)%s", p.dim, h.CommitDate.In(p.loc).Format("2006-01-02"), p.reset)
 		}
-		p.row(p.emoji("👤", "@"), "Last commit", pr.CommitAuthor+dateStr)
+		p.row(p.emoji("👤", "@"), "Last commit", h.CommitAuthor+dateStr)
 	}
-	if pr.CommitMessage != "" {
-		msg := pr.CommitMessage
+	if h.CommitMessage != "" {
+		msg := h.CommitMessage
 		if len(msg) > 70 {
 			msg = msg[:67] + "..."
 		}
 		p.row(p.emoji("💬", ">"), "Message", p.dim+msg+p.reset)
 	}
-	fmt.Println()
+	if deployedSHA != "" {
+		p.printDeployedCommit(deployedSHA, h.HeadSHA, headLabel)
+	}
 }
 
-func (p *Printer) printStatus(cluster *kube.ClusterInfo, pr *github.PRInfo, reg *registry.Result, edition string) {
-	p.section(p.emoji("📊", "[ST]") + " STATUS")
+// printChecks summarizes every check on the PR head. Only the GraphQL query
+// fetches them, so anonymous runs print nothing.
+func (p *Printer) printChecks(pr *github.PRInfo) {
+	if pr.ChecksState == "" {
+		return
+	}
+	completed, failed := checksSummary(pr.Checks)
+	color := p.yellow
+	switch pr.ChecksState {
+	case "success":
+		color = p.green
+	case "failure", "error":
+		color = p.red
+	}
+	value := fmt.Sprintf("%s%s%s %s(%d/%d completed)%s", color, pr.ChecksState, p.reset, p.dim, completed, len(pr.Checks), p.reset)
+	if len(failed) > 0 {
+		value += fmt.Sprintf(" %sfailed: %s%s", p.red, truncate(strings.Join(failed, ", "), 50), p.reset)
+	}
+	p.row(p.emoji("🚦", "*"), "Checks", value)
+}
 
-	buildActive := pr != nil && (pr.BuildStatus == "in_progress" || pr.BuildStatus == "queued")
+func (p *Printer) printRelease(r *github.ReleaseInfo) {
+	p.row(p.emoji("🏷️", "#"), "Release", p.bold+r.Tag+p.reset)
+	if r.URL != "" {
+		p.row(p.emoji("🔗", "~"), "URL", p.dim+r.URL+p.reset)
+	}
+	if !r.PublishedAt.IsZero() {
+		p.row(p.emoji("📅", "D"), "Published", r.PublishedAt.In(p.loc).Format("2006-01-02"))
+	}
+	if !r.CachedAt.IsZero() {
+		p.row(p.emoji("🗄️", "C"), "Cached", p.yellow+cachedAgo(r.CachedAt)+p.reset+p.dim+" (GitHub unreachable)"+p.reset)
+	}
+	if r.LatestPatch == r.Tag {
+		p.row(p.emoji("⬆️", "^"), "Latest patch", p.green+r.LatestPatch+p.reset)
+		return
+	}
+	p.row(p.emoji("⬆️", "^"), "Latest patch", fmt.Sprintf("%s%s%s %s%s%s", p.yellow, r.LatestPatch, p.reset, p.dim, r.LatestPatchURL, p.reset))
+}
 
-	switch {
-	case buildActive:
-		p.printBuildBasedStatus(cluster, pr, edition)
-
-	case reg != nil && reg.TagExists && reg.Digest != "":
-		if reg.DigestMatch {
-			p.statusRow(p.emoji("✅", "[OK]"), p.green, "Up to date", "digest matches registry")
-		} else {
-			p.statusRow(p.emoji("⚠️", "[!]"), p.yellow, "Outdated", "registry has newer image for tag")
-			p.row(p.emoji("🔄", "->"), "Action", p.yellow+"restart pod to update"+p.reset)
-		}
+// maxPendingCommits caps the commit list in the full output.
+const maxPendingCommits = 10
 
-	case pr != nil && pr.BuildStatus != "":
-		p.printBuildBasedStatus(cluster, pr, edition)
+func (p *Printer) printPendingCommits(cmp *github.Comparison, cmpErr error) {
+	if cmpErr == nil && (cmp == nil || cmp.AheadBy == 0) {
+		return
+	}
 
-	case pr != nil && pr.BuildStatus == "":
-		p.statusRow(p.emoji("⏳", "[..]"), p.cyan, "Waiting for CI", fmt.Sprintf("Build %s not started yet", edition))
+	p.section(p.emoji("📥", "[NEW]") + " NOT DEPLOYED")
+	if cmpErr != nil {
+		p.row(p.emoji("⚠️", "!"), "Error", p.red+cmpErr.Error()+p.reset)
+		fmt.Println()
+		return
+	}
 
-	case reg != nil && reg.Err != nil:
-		p.statusRow(p.emoji("❓", "[?]"), "", "Cannot determine", fmt.Sprintf("registry: %s", reg.Err))
+	p.row(p.emoji("📥", "<-"), "Behind", p.yellow+pluralCommits(cmp.AheadBy)+p.reset)
 
-	default:
-		p.statusRow(p.emoji("❓", "[?]"), "", "Cannot determine", "no registry tag, no build info")
+	// Newest first; GitHub returns them oldest first.
+	shown := 0
+	for i := len(cmp.Commits) - 1; i >= 0 && shown < maxPendingCommits; i-- {
+		c := cmp.Commits[i]
+		age := ""
+		if !c.Date.IsZero() {
+			age = fmt.Sprintf(" %s%s ago%s", p.dim, humanDuration(time.Since(c.Date)), p.reset)
+		}
+		fmt.Printf("   %s%s%s %s%s — %s\n", p.dim, github.ShortSHA(c.SHA), p.reset, c.Author, age, truncate(c.Message, 60))
+		shown++
 	}
-
-	if reg != nil && !p.cfg.NoRegistry {
-		p.printRegistryLine(reg)
+	if rest := cmp.AheadBy - shown; rest > 0 {
+		fmt.Printf("   %s... and %d more%s\n", p.dim, rest, p.reset)
 	}
-
 	fmt.Println()
 }
 
-func (p *Printer) printBuildBasedStatus(cluster *kube.ClusterInfo, pr *github.PRInfo, edition string) {
-	buildName := "Build " + edition
+func (p *Printer) printDeployedCommit(deployed, head, headLabel string) {
+	value := fmt.Sprintf("%s %s(head %s)%s", github.ShortSHA(deployed), p.dim, github.ShortSHA(head), p.reset)
+	if deployed == head {
+		value = fmt.Sprintf("%s %s(%s)%s", github.ShortSHA(deployed), p.green, headLabel, p.reset)
+	}
+	p.row(p.emoji("📌", "@"), "Deployed", value)
+}
 
-	switch {
-	case pr.BuildStatus == "in_progress" || pr.BuildStatus == "queued":
-		p.statusRow(p.emoji("🔄", "[~]"), p.cyan, "Building", fmt.Sprintf("%s is running...", buildName))
-
-	case pr.BuildConclusion == "success" && !pr.BuildCompletedAt.IsZero():
-		podTime := cluster.PodCreated
-		buildTime := pr.BuildCompletedAt
-		podFmt := podTime.In(p.loc).Format("15:04")
-		buildFmt := buildTime.In(p.loc).Format("15:04")
-
-		if podTime.After(buildTime) {
-			p.statusRow(p.emoji("✅", "[OK]"), p.green, "Up to date", fmt.Sprintf("pod created after %s: %s > %s", buildName, podFmt, buildFmt))
-		} else {
-			p.statusRow(p.emoji("⚠️", "[!]"), p.yellow, "Outdated", fmt.Sprintf("%s completed after pod: %s > %s", buildName, buildFmt, podFmt))
-			p.row(p.emoji("🔄", "->"), "Action", p.yellow+"restart pod to update"+p.reset)
-		}
+func (p *Printer) printStatus(v verdict.Verdict, reg *registry.Result) {
+	p.section(p.emoji("📊", "[ST]") + " STATUS")
 
-	case pr.BuildConclusion == "failure":
-		p.statusRow(p.emoji("❌", "[X]"), p.red, "Build failed", fmt.Sprintf("%s failed on last commit", buildName))
+	icon, color := p.verdictStyle(v.Kind)
+	p.statusRow(icon, color, v.Kind.Title(), v.Reason)
+	if v.Health != "" {
+		p.row(p.emoji("🩺", "[X]"), "Health", fmt.Sprintf("%s%s%s  %s(%s)%s", p.red, v.Health, p.reset, p.dim, v.HealthReason, p.reset))
+	}
+	if v.Action != verdict.ActionNone {
+		p.row(p.emoji("🔄", "->"), "Action", p.yellow+string(v.Action)+p.reset)
+	}
 
-	default:
-		p.statusRow(p.emoji("❓", "[?]"), "", "Cannot determine", fmt.Sprintf("%s status: %s", buildName, pr.BuildStatus))
+	if reg != nil && !p.cfg.NoRegistry {
+		p.printRegistryLine(reg)
 	}
+
+	fmt.Println()
 }
 
 func (p *Printer) printRegistryLine(reg *registry.Result) {
@@ -142,6 +348,8 @@ func (p *Printer) printRegistryLine(reg *registry.Result) {
 	switch {
 	case reg.Err != nil:
 		msg = fmt.Sprintf("error (%s)", reg.Err)
+	case reg.TagExists && reg.Platform != "":
+		msg = fmt.Sprintf("tag available (multi-arch index, running %s)", reg.Platform)
 	case reg.TagExists:
 		msg = "tag available"
 	case reg.ImageExists:
@@ -152,47 +360,55 @@ func (p *Printer) printRegistryLine(reg *registry.Result) {
 	p.row(p.emoji("ℹ️", "i"), "Registry", p.dim+msg+p.reset)
 }
 
-// statusLine returns a one-line status string (for short mode).
-func (p *Printer) statusLine(cluster *kube.ClusterInfo, pr *github.PRInfo, reg *registry.Result, edition string) string {
-	buildActive := pr != nil && (pr.BuildStatus == "in_progress" || pr.BuildStatus == "queued")
+// statusLine returns a one-line status string (for short mode), followed by
+// the health problem of the pod if any: "Up to date, but CrashLoopBackOff".
+func (p *Printer) statusLine(v verdict.Verdict) string {
+	line := p.kindLine(v)
+	if v.Health != "" {
+		line += fmt.Sprintf("%s, but %s%s", p.red, v.Health, p.reset)
+	}
+	return line
+}
 
-	switch {
-	case buildActive:
-		return fmt.Sprintf("%s%s Building...%s", p.cyan, p.emoji("🔄", "[~]"), p.reset)
+func (p *Printer) kindLine(v verdict.Verdict) string {
+	icon, color := p.verdictStyle(v.Kind)
+	title := v.Kind.Title()
 
-	case reg != nil && reg.TagExists && reg.Digest != "":
-		if reg.DigestMatch {
-			return fmt.Sprintf("%s%s Up to date%s", p.green, p.emoji("✅", "[OK]"), p.reset)
-		}
-		return fmt.Sprintf("%s%s Outdated%s", p.yellow, p.emoji("⚠️", "[!]"), p.reset)
-
-	case pr != nil && pr.BuildStatus != "":
-		buildName := "Build " + edition
-		switch {
-		case pr.BuildStatus == "in_progress" || pr.BuildStatus == "queued":
-			return fmt.Sprintf("%s%s Building...%s", p.cyan, p.emoji("🔄", "[~]"), p.reset)
-		case pr.BuildConclusion == "success" && !pr.BuildCompletedAt.IsZero():
-			if cluster.PodCreated.After(pr.BuildCompletedAt) {
-				return fmt.Sprintf("%s%s Up to date%s", p.green, p.emoji("✅", "[OK]"), p.reset)
-			}
-			return fmt.Sprintf("%s%s Outdated%s %s(new %s)%s", p.yellow, p.emoji("⚠️", "[!]"), p.reset, p.dim, buildName, p.reset)
-		case pr.BuildConclusion == "failure":
-			return fmt.Sprintf("%s%s Build failed%s", p.red, p.emoji("❌", "[X]"), p.reset)
+	switch v.Kind {
+	case verdict.Building, verdict.WaitingForCI:
+		return fmt.Sprintf("%s%s %s...%s", color, icon, title, p.reset)
+	case verdict.Outdated:
+		if v.Source == verdict.SourceBuild {
+			return fmt.Sprintf("%s%s %s%s %s(new build)%s", color, icon, title, p.reset, p.dim, p.reset)
 		}
-
-	case pr != nil && pr.BuildStatus == "":
-		return fmt.Sprintf("%s%s Waiting for CI...%s", p.cyan, p.emoji("⏳", "[..]"), p.reset)
+	case verdict.Unknown:
+		return fmt.Sprintf("%s %s %s(%s)%s", icon, title, p.dim, v.Reason, p.reset)
 	}
+	return fmt.Sprintf("%s%s %s%s", color, icon, title, p.reset)
+}
 
-	return fmt.Sprintf("%s Unknown%s", p.emoji("❓", "[?]"), p.reset)
+// verdictStyle returns the icon and color used for a verdict kind.
+func (p *Printer) verdictStyle(k verdict.Kind) (icon, color string) {
+	switch k {
+	case verdict.UpToDate:
+		return p.emoji("✅", "[OK]"), p.green
+	case verdict.Outdated:
+		return p.emoji("⚠️", "[!]"), p.yellow
+	case verdict.Building:
+		return p.emoji("🔄", "[~]"), p.cyan
+	case verdict.WaitingForCI:
+		return p.emoji("⏳", "[..]"), p.cyan
+	case verdict.BuildFailed:
+		return p.emoji("❌", "[X]"), p.red
+	case verdict.MixedRollout:
+		return p.emoji("🔀", "[~]"), p.yellow
+	default:
+		return p.emoji("❓", "[?]"), ""
+	}
 }
 
 // PrintWatchHeader prints a brief header for the watch-build command to stderr.
-func (p *Printer) PrintWatchHeader(prNumber int, edition, sha string) {
-	shortSHA := sha
-	if len(shortSHA) > 12 {
-		shortSHA = shortSHA[:12]
-	}
-	fmt.Fprintf(os.Stderr, "%s%sWatching Build %s for PR #%d%s\n", p.bold, p.cyan, edition, prNumber, p.reset)
-	fmt.Fprintf(os.Stderr, "%sCommit: %s%s\n\n", p.dim, shortSHA, p.reset)
+func (p *Printer) PrintWatchHeader(prNumber int, checkName, sha string) {
+	fmt.Fprintf(os.Stderr, "%s%sWatching %s for PR #%d%s\n", p.bold, p.cyan, checkName, prNumber, p.reset)
+	fmt.Fprintf(os.Stderr, "%sCommit: %s%s\n\n", p.dim, github.ShortSHA(sha), p.reset)
 }
@@ -90,6 +90,13 @@ func (s *Spinner) Tick(message string) {
 	s.mu.Unlock()
 }
 
+// Log prints a message on its own line; the spinner keeps running below it.
+func (s *Spinner) Log(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "\r\033[K%s%s%s\n", s.dim, message, s.reset)
+}
+
 // ClearLine clears the current spinner line.
 func (s *Spinner) ClearLine() {
 	s.mu.Lock()
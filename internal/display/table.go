@@ -0,0 +1,75 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/imagetag"
+)
+
+// ClusterRow is one cluster in the status-all table.
+type ClusterRow struct {
+	Name string      // kubeconfig context
+	Data *RenderData // nil when Err is set
+	Err  error
+}
+
+var tableHeader = []string{"CLUSTER", "TAG", "PR", "EDITION", "POD AGE", "STATUS"}
+
+// RenderTable prints a compact one-row-per-cluster overview.
+func (p *Printer) RenderTable(rows []ClusterRow) {
+	cells := make([][]string, len(rows))
+	statuses := make([]string, len(rows))
+	for i, r := range rows {
+		cells[i], statuses[i] = p.tableRow(r)
+	}
+
+	// Pad on plain text; the status column is last and carries the ANSI codes.
+	widths := make([]int, len(tableHeader)-1)
+	for i := range widths {
+		widths[i] = len(tableHeader[i])
+		for _, c := range cells {
+			widths[i] = max(widths[i], len(c[i]))
+		}
+	}
+
+	var header strings.Builder
+	for i, w := range widths {
+		fmt.Fprintf(&header, "%-*s  ", w, tableHeader[i])
+	}
+	fmt.Printf("%s%s%s%s\n", p.bold, header.String(), tableHeader[len(tableHeader)-1], p.reset)
+
+	for i, c := range cells {
+		var line strings.Builder
+		for j, w := range widths {
+			fmt.Fprintf(&line, "%-*s  ", w, c[j])
+		}
+		fmt.Printf("%s%s\n", line.String(), statuses[i])
+	}
+}
+
+func (p *Printer) tableRow(r ClusterRow) (cells []string, status string) {
+	if r.Err != nil {
+		return []string{r.Name, "-", "-", "-", "-"},
+			fmt.Sprintf("%s%s %s%s", p.red, p.emoji("⚠️", "[!]"), r.Err, p.reset)
+	}
+
+	d := r.Data
+	pr, edition := "-", "-"
+	if d.Tag.Kind == imagetag.PR {
+		pr = fmt.Sprintf("#%d", d.Tag.PRNumber)
+	}
+	if d.Tag.Kind != imagetag.Unknown {
+		edition = d.Tag.Edition
+	}
+
+	cells = []string{
+		r.Name,
+		d.Cluster.Tag,
+		pr,
+		edition,
+		humanDuration(time.Since(d.Cluster.PodCreated)),
+	}
+	return cells, p.statusLine(p.evaluate(*d))
+}
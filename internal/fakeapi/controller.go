@@ -0,0 +1,152 @@
+package fakeapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// ControllerPort is the port the fake controller answers on.
+const ControllerPort = "4222"
+
+// Controller is a fake deckhouse-controller HTTP server reached through the
+// pod proxy of a fake clientset. It serves /readyz and /metrics for every
+// pod. Its methods are safe to call while a test client is using it.
+type Controller struct {
+	mu           sync.Mutex
+	converging   bool
+	queues       map[string]int
+	moduleErrors map[string]int
+	failures     map[string]int // path -> HTTP status code
+	requests     int
+}
+
+// NewController installs a fake controller into cs. It starts converged with
+// an empty main queue.
+func NewController(cs *fake.Clientset) *Controller {
+	c := &Controller{queues: map[string]int{"main": 0}, moduleErrors: make(map[string]int), failures: make(map[string]int)}
+	cs.PrependProxyReactor("pods", c.react)
+	return c
+}
+
+// SetConverging makes /readyz fail, like during the first converge after start.
+func (c *Controller) SetConverging(converging bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.converging = converging
+}
+
+// SetQueue sets the number of tasks in a queue, e.g. "main".
+func (c *Controller) SetQueue(name string, tasks int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queues[name] = tasks
+}
+
+// SetModuleErrors sets the hook error counter of a module.
+func (c *Controller) SetModuleErrors(module string, errors int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.moduleErrors[module] = errors
+}
+
+// SetFailure makes requests to path fail with an HTTP status code, e.g. 403
+// for a denied pods/proxy or 404 for a controller without the endpoint; 0
+// removes the failure.
+func (c *Controller) SetFailure(path string, code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures[path] = code
+}
+
+// Requests returns the number of proxied requests served so far.
+func (c *Controller) Requests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests
+}
+
+func (c *Controller) react(action k8stesting.Action) (bool, restclient.ResponseWrapper, error) {
+	a, ok := action.(k8stesting.ProxyGetAction)
+	if !ok {
+		return false, nil, nil
+	}
+	if a.GetPort() != ControllerPort {
+		return true, proxyResponse{err: fmt.Errorf("dial tcp %s:%s: connection refused", a.GetName(), a.GetPort())}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+
+	if code := c.failures[a.GetPath()]; code != 0 {
+		return true, proxyResponse{err: apierrors.NewGenericServerResponse(code, "get", schema.GroupResource{Resource: "pods"}, a.GetName(), "", 0, true)}, nil
+	}
+	switch a.GetPath() {
+	case "/readyz":
+		if c.converging {
+			return true, proxyResponse{err: apierrors.NewGenericServerResponse(500, "get", schema.GroupResource{Resource: "pods"}, a.GetName(), "Startup converge in progress", 0, true)}, nil
+		}
+		return true, proxyResponse{body: "ok"}, nil
+	case "/metrics":
+		return true, proxyResponse{body: c.metrics()}, nil
+	}
+	return true, proxyResponse{err: apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, a.GetName())}, nil
+}
+
+// metrics renders the Prometheus text format with the metric names of
+// addon-operator under the "deckhouse_" prefix.
+func (c *Controller) metrics() string {
+	var b strings.Builder
+	b.WriteString("# HELP deckhouse_tasks_queue_length Tasks in a queue.\n# TYPE deckhouse_tasks_queue_length gauge\n")
+	for _, name := range sortedKeys(c.queues) {
+		fmt.Fprintf(&b, "deckhouse_tasks_queue_length{queue=%q} %d\n", name, c.queues[name])
+	}
+	b.WriteString("# TYPE deckhouse_module_hook_errors_total counter\n")
+	for _, module := range sortedKeys(c.moduleErrors) {
+		fmt.Fprintf(&b, "deckhouse_module_hook_errors_total{binding=\"beforeHelm\",hook=\"%s/hooks/sync\",module=%q,queue=\"main\"} %d\n", module, module, c.moduleErrors[module])
+		// Allowed errors are not failures.
+		fmt.Fprintf(&b, "deckhouse_module_hook_allowed_errors_total{hook=\"%s/hooks/sync\",module=%q} 7\n", module, module)
+	}
+	b.WriteString("deckhouse_live_ticks 42 1700000000000\n")
+	return b.String()
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// proxyResponse is a pod proxy response. The fake clientset drops errors
+// returned by reactors, so failures are carried here.
+type proxyResponse struct {
+	body string
+	err  error
+}
+
+func (r proxyResponse) DoRaw(context.Context) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []byte(r.body), nil
+}
+
+func (r proxyResponse) Stream(context.Context) (io.ReadCloser, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return io.NopCloser(strings.NewReader(r.body)), nil
+}
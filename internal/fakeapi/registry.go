@@ -0,0 +1,246 @@
+package fakeapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	mediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIConfig   = "application/vnd.oci.image.config.v1+json"
+
+	registryToken = "fake-registry-token"
+)
+
+// Image is an image pushed to Registry.
+type Image struct {
+	Revision string    // org.opencontainers.image.revision label
+	Created  time.Time // config created time
+	Index    bool      // push as a linux/amd64 image index, like multi-platform builds
+}
+
+type blob struct {
+	mediaType string
+	body      []byte
+}
+
+// Registry is a fake Docker Registry v2 with bearer token auth. Its methods
+// are safe to call while a test client is using it.
+type Registry struct {
+	*httptest.Server
+
+	// Auth, if set, is the base64 user:password the token endpoint requires.
+	Auth string
+
+	mu        sync.Mutex
+	blobs     map[string]blob              // by digest: manifests and configs
+	tags      map[string]map[string]string // repo → tag → digest
+	manifests map[string]map[string]bool   // repo → manifest digests
+	corrupt   map[string]bool              // manifest digests served as garbage
+}
+
+// NewRegistry starts a fake registry. It is closed with the test.
+func NewRegistry(t testing.TB) *Registry {
+	r := &Registry{
+		blobs:     make(map[string]blob),
+		tags:      make(map[string]map[string]string),
+		manifests: make(map[string]map[string]bool),
+		corrupt:   make(map[string]bool),
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// BasicAuth encodes user and password as in a .dockerconfigjson auth entry.
+func BasicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
+
+// Push stores img in repo under tag, replacing what the tag pointed to, and
+// returns the digest of its platform manifest: what a container runtime
+// reports as the running image.
+func (r *Registry) Push(repo, tag string, img Image) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := map[string]any{
+		"created": img.Created.Format(time.RFC3339),
+		"config":  map[string]any{"Labels": map[string]string{"org.opencontainers.image.revision": img.Revision}},
+	}
+	cfgDigest := r.store(repo, mediaTypeOCIConfig, cfg, false)
+
+	manifestDigest := r.store(repo, mediaTypeOCIManifest, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeOCIManifest,
+		"config":        map[string]any{"mediaType": mediaTypeOCIConfig, "digest": cfgDigest},
+		"layers":        []any{},
+	}, true)
+
+	tagDigest := manifestDigest
+	if img.Index {
+		// An arm64 sibling with its own config comes first, so a client that
+		// ignores the platform reads the wrong labels.
+		armCfg := r.store(repo, mediaTypeOCIConfig, map[string]any{
+			"created": img.Created.Format(time.RFC3339),
+			"config":  map[string]any{"Labels": map[string]string{"org.opencontainers.image.revision": img.Revision + "-arm64"}},
+		}, false)
+		armDigest := r.store(repo, mediaTypeOCIManifest, map[string]any{
+			"schemaVersion": 2,
+			"mediaType":     mediaTypeOCIManifest,
+			"config":        map[string]any{"mediaType": mediaTypeOCIConfig, "digest": armCfg},
+			"layers":        []any{},
+		}, true)
+		tagDigest = r.store(repo, mediaTypeOCIIndex, map[string]any{
+			"schemaVersion": 2,
+			"mediaType":     mediaTypeOCIIndex,
+			"manifests": []any{
+				map[string]any{
+					"mediaType": mediaTypeOCIManifest,
+					"digest":    armDigest,
+					"platform":  map[string]string{"os": "linux", "architecture": "arm64"},
+				},
+				map[string]any{
+					"mediaType": mediaTypeOCIManifest,
+					"digest":    manifestDigest,
+					"platform":  map[string]string{"os": "linux", "architecture": "amd64"},
+				},
+			},
+		}, true)
+	}
+
+	if r.tags[repo] == nil {
+		r.tags[repo] = make(map[string]string)
+	}
+	r.tags[repo][tag] = tagDigest
+	return manifestDigest
+}
+
+// TagDigest returns the digest the tag points to: that of the index for
+// images pushed with Index.
+func (r *Registry) TagDigest(repo, tag string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tags[repo][tag]
+}
+
+// Corrupt makes GETs of the manifest with digest return a body that is not
+// JSON; HEAD still succeeds.
+func (r *Registry) Corrupt(digest string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.corrupt[digest] = true
+}
+
+// DeleteTag removes a tag; the images stay reachable by digest.
+func (r *Registry) DeleteTag(repo, tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tags[repo], tag)
+}
+
+func (r *Registry) store(repo, mediaType string, v any, manifest bool) string {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(body)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	r.blobs[digest] = blob{mediaType: mediaType, body: body}
+	if manifest {
+		if r.manifests[repo] == nil {
+			r.manifests[repo] = make(map[string]bool)
+		}
+		r.manifests[repo][digest] = true
+	}
+	return digest
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.URL.Path == "/token":
+		r.serveToken(w, req)
+		return
+	case !strings.HasPrefix(req.URL.Path, "/v2/"):
+		http.NotFound(w, req)
+		return
+	}
+
+	if req.Header.Get("Authorization") != "Bearer "+registryToken {
+		w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake-registry"`, r.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if req.URL.Path == "/v2/" {
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if repo, ref, ok := strings.Cut(path, "/manifests/"); ok {
+		r.serveManifest(w, req, repo, ref)
+		return
+	}
+	if _, digest, ok := strings.Cut(path, "/blobs/"); ok {
+		r.serveBlob(w, req, digest)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	if r.Auth != "" && req.Header.Get("Authorization") != "Basic "+r.Auth {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": registryToken})
+}
+
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	r.mu.Lock()
+	digest := ref
+	if !strings.HasPrefix(ref, "sha256:") {
+		digest = r.tags[repo][ref]
+	}
+	b, ok := r.blobs[digest]
+	ok = ok && r.manifests[repo][digest]
+	corrupt := r.corrupt[digest]
+	r.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", b.mediaType)
+	w.Header().Set("Docker-Content-Digest", digest)
+	if req.Method == http.MethodHead {
+		return
+	}
+	if corrupt {
+		_, _ = w.Write([]byte("<html>"))
+		return
+	}
+	_, _ = w.Write(b.body)
+}
+
+func (r *Registry) serveBlob(w http.ResponseWriter, req *http.Request, digest string) {
+	r.mu.Lock()
+	b, ok := r.blobs[digest]
+	r.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", b.mediaType)
+	_, _ = w.Write(b.body)
+}
@@ -0,0 +1,174 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheRetention is how long unused cache entries are kept on disk.
+const cacheRetention = 7 * 24 * time.Hour
+
+// responseCache is an on-disk cache of GitHub responses keyed by request. Each
+// entry is written to a temporary file and renamed into place, so concurrent
+// processes (several SSH logins at once) never see a partial entry; the last
+// writer wins.
+type responseCache struct {
+	dir    string
+	maxAge time.Duration // entries younger than this are used without a request
+	token  string        // part of every key
+}
+
+type cacheEntry struct {
+	URL       string          `json:"url"`
+	ETag      string          `json:"etag,omitempty"`
+	Link      string          `json:"link,omitempty"`
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// DefaultCacheDir returns $XDG_CACHE_HOME/deckhouse-status, falling back to ~/.cache.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "deckhouse-status")
+}
+
+// EnableCache stores GET and GraphQL responses under dir. Entries younger than
+// maxAge are served without a request; older ones are revalidated with their
+// ETag, and served stale if GitHub is unreachable or rate limited.
+// It must be called before the client is used.
+func (c *Client) EnableCache(dir string, maxAge time.Duration) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	c.cache = &responseCache{dir: dir, maxAge: maxAge, token: c.token}
+	c.cache.prune()
+	return nil
+}
+
+// SetCacheMaxAge changes the max-age of an enabled cache; 0 always revalidates.
+// Like EnableCache it must be called before the client is used.
+func (c *Client) SetCacheMaxAge(maxAge time.Duration) {
+	if c.cache != nil {
+		c.cache.maxAge = maxAge
+	}
+}
+
+// path returns the entry file for key. The token is part of the key so users
+// with different access never share entries.
+func (c *responseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(c.token + "\n" + key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *responseCache) load(key string) *cacheEntry {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if json.Unmarshal(data, &e) != nil || e.URL != key {
+		return nil
+	}
+	return &e
+}
+
+// store writes e; failures only cost a future request, so they are ignored.
+func (c *responseCache) store(key string, e *cacheEntry) {
+	e.URL = key
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), c.path(key)) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (c *responseCache) fresh(e *cacheEntry) bool {
+	return time.Since(e.FetchedAt) < c.maxAge
+}
+
+// prune removes entries not written for cacheRetention, and temporary files
+// left behind by killed processes.
+func (c *responseCache) prune() {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		age := time.Since(info.ModTime())
+		if age > cacheRetention || strings.HasSuffix(f.Name(), ".tmp") && age > time.Hour {
+			_ = os.Remove(filepath.Join(c.dir, f.Name()))
+		}
+	}
+}
+
+// servesStale reports whether err means GitHub could not answer (network
+// failure, rate limit, server error), so a stale cache entry is better than nothing.
+func servesStale(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var rl *RateLimitError
+	if errors.As(err, &rl) {
+		return true
+	}
+	var he *httpError
+	if !errors.As(err, &he) {
+		return true
+	}
+	return he.StatusCode >= 500
+}
+
+// staleTracker records the oldest stale cache entry served while fetching
+// one piece of data, so callers can tell the user how old it is.
+type staleTracker struct {
+	mu    sync.Mutex
+	since time.Time
+}
+
+type staleTrackerKey struct{}
+
+func withStaleTracker(ctx context.Context) (context.Context, *staleTracker) {
+	t := &staleTracker{}
+	return context.WithValue(ctx, staleTrackerKey{}, t), t
+}
+
+func markStale(ctx context.Context, fetchedAt time.Time) {
+	t, ok := ctx.Value(staleTrackerKey{}).(*staleTracker)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	if t.since.IsZero() || fetchedAt.Before(t.since) {
+		t.since = fetchedAt
+	}
+	t.mu.Unlock()
+}
+
+func (t *staleTracker) oldest() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.since
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheRoundTrip(t *testing.T) {
+	c := &responseCache{dir: t.TempDir(), maxAge: time.Hour}
+	key := "https://api.github.com/repos/deckhouse/deckhouse/pulls/1"
+
+	if e := c.load(key); e != nil {
+		t.Fatalf("empty cache loaded %+v", e)
+	}
+	c.store(key, &cacheEntry{ETag: `"v1"`, FetchedAt: time.Now(), Body: []byte(`{"number":1}`)})
+	e := c.load(key)
+	if e == nil || e.ETag != `"v1"` || string(e.Body) != `{"number":1}` {
+		t.Fatalf("load = %+v, want the stored entry", e)
+	}
+	if !c.fresh(e) {
+		t.Error("entry fetched now is not fresh")
+	}
+	e.FetchedAt = time.Now().Add(-2 * time.Hour)
+	if c.fresh(e) {
+		t.Error("entry fetched two hours ago is fresh with a max-age of an hour")
+	}
+	if e := c.load(key + "/commits"); e != nil {
+		t.Errorf("other key loaded %+v", e)
+	}
+}
+
+func TestServesStale(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("dial tcp: connection refused"), true},
+		{&RateLimitError{RetryAt: time.Now().Add(time.Minute)}, true},
+		{&RateLimitError{Secondary: true}, true},
+		{fmt.Errorf("fetch PR: %w", &httpError{StatusCode: http.StatusBadGateway}), true},
+		{&httpError{StatusCode: http.StatusNotFound}, false},
+		{&httpError{StatusCode: http.StatusUnauthorized}, false},
+		{&httpError{StatusCode: http.StatusForbidden}, false},
+		{context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := servesStale(tt.err); got != tt.want {
+			t.Errorf("servesStale(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
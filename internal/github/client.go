@@ -3,18 +3,87 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/httpretry"
 )
 
-const apiBase = "https://api.github.com"
+// DefaultBaseURL is the public GitHub REST and GraphQL API.
+const DefaultBaseURL = "https://api.github.com"
+
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "deckhouse-status"
+
+// DefaultRepo is the repository Deckhouse PR builds come from.
+const DefaultRepo = "deckhouse/deckhouse"
+
+// ParseRepo splits an "owner/name" repository reference.
+func ParseRepo(s string) (owner, repo string, err error) {
+	owner, repo, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("invalid repository %q (want owner/name)", s)
+	}
+	return owner, repo, nil
+}
+
+// Options configures a Client. Empty fields take the defaults.
+type Options struct {
+	BaseURL    string       // API root, default DefaultBaseURL
+	HTTPClient *http.Client // default httpretry.Client
+	Token      string       // GITHUB_TOKEN; empty means anonymous access
+	UserAgent  string       // default DefaultUserAgent
+}
+
+// Client talks to the GitHub API. It keeps the response cache and the last
+// known rate limits; it is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	userAgent  string
+
+	cache *responseCache // nil when caching is disabled
+
+	rateMu     sync.Mutex
+	rateLimits map[string]RateLimit
+}
+
+// NewClient creates a GitHub client.
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(opts.BaseURL, "/"),
+		httpClient: opts.HTTPClient,
+		token:      opts.Token,
+		userAgent:  opts.UserAgent,
+		rateLimits: make(map[string]RateLimit),
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = httpretry.Client
+	}
+	if c.userAgent == "" {
+		c.userAgent = DefaultUserAgent
+	}
+	return c
+}
+
+// HasToken reports whether the client has a token; write calls require it.
+func (c *Client) HasToken() bool {
+	return c.token != ""
+}
 
-func fetchCommitInfo(ctx context.Context, owner, repo, sha string) (commitInfo, error) {
-	commitURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s", apiBase, owner, repo, sha)
+func (c *Client) fetchCommitInfo(ctx context.Context, owner, repo, sha string) (commitInfo, error) {
+	commitURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, owner, repo, sha)
 	var resp commitResponse
-	if err := getJSON(ctx, commitURL, &resp); err != nil {
+	if err := c.getJSON(ctx, commitURL, &resp); err != nil {
 		return commitInfo{}, err
 	}
 	info := commitInfo{
@@ -27,16 +96,36 @@ func fetchCommitInfo(ctx context.Context, owner, repo, sha string) (commitInfo,
 	return info, nil
 }
 
-func fetchCheckRun(ctx context.Context, owner, repo, sha, checkName string) (*CheckRunResult, error) {
+// FindCheckRun returns the latest check-run of the first of checkNames that
+// exists on sha, and that name. When none exists yet the result is empty and
+// the name is checkNames[0]. Each missing name costs one API call.
+func (c *Client) FindCheckRun(ctx context.Context, owner, repo, sha string, checkNames []string) (*CheckRunResult, string, error) {
+	if len(checkNames) == 0 {
+		return &CheckRunResult{}, "", nil
+	}
+	for _, name := range checkNames {
+		cr, err := c.fetchCheckRun(ctx, owner, repo, sha, name)
+		if err != nil {
+			return nil, name, fmt.Errorf("fetch check-run %q for %s: %w", name, ShortSHA(sha), err)
+		}
+		if cr.ID != 0 {
+			return cr, name, nil
+		}
+	}
+	return &CheckRunResult{}, checkNames[0], nil
+}
+
+func (c *Client) fetchCheckRun(ctx context.Context, owner, repo, sha, checkName string) (*CheckRunResult, error) {
 	checkURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-runs?check_name=%s&per_page=1",
-		apiBase, owner, repo, sha, url.QueryEscape(checkName))
+		c.baseURL, owner, repo, sha, url.QueryEscape(checkName))
 	var resp checkRunsResponse
-	if err := getJSON(ctx, checkURL, &resp); err != nil {
+	if err := c.getJSON(ctx, checkURL, &resp); err != nil {
 		return nil, err
 	}
 	result := &CheckRunResult{}
 	if len(resp.CheckRuns) > 0 {
 		cr := resp.CheckRuns[0]
+		result.ID = cr.ID
 		result.Status = cr.Status
 		result.Conclusion = cr.Conclusion
 		if t, err := time.Parse(time.RFC3339, cr.CompletedAt); err == nil {
@@ -47,23 +136,73 @@ func fetchCheckRun(ctx context.Context, owner, repo, sha, checkName string) (*Ch
 }
 
 // FetchHeadSHA returns the head commit SHA for a pull request (1 API call).
-func FetchHeadSHA(ctx context.Context, owner, repo string, prNumber int) (string, error) {
-	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", apiBase, owner, repo, prNumber)
+func (c *Client) FetchHeadSHA(ctx context.Context, owner, repo string, prNumber int) (string, error) {
+	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, repo, prNumber)
 	var resp pullRequestResponse
-	if err := getJSON(ctx, prURL, &resp); err != nil {
+	if err := c.getJSON(ctx, prURL, &resp); err != nil {
 		return "", fmt.Errorf("fetch PR #%d head SHA: %w", prNumber, err)
 	}
 	return resp.Head.SHA, nil
 }
 
+// PollHeadSHA fetches the PR head commit SHA with ETag support for cheap re-checks.
+// When ETag is provided and server returns 304, result.NotModified will be true.
+func (c *Client) PollHeadSHA(ctx context.Context, req PollHeadSHARequest) (*HeadSHAResult, error) {
+	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, req.Owner, req.Repo, req.PRNumber)
+
+	var resp pullRequestResponse
+	cond, err := c.getJSONConditional(ctx, prURL, req.ETag, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("fetch PR #%d head SHA: %w", req.PRNumber, err)
+	}
+
+	result := &HeadSHAResult{ETag: cond.ETag, NotModified: cond.NotModified}
+	if !cond.NotModified {
+		result.SHA = resp.Head.SHA
+	}
+	return result, nil
+}
+
+// FetchCompare returns the commits reachable from head but not from base (1 API call).
+// GitHub returns at most 250 commits, oldest first; AheadBy is always exact.
+func (c *Client) FetchCompare(ctx context.Context, owner, repo, base, head string) (*Comparison, error) {
+	compareURL := fmt.Sprintf("%s/repos/%s/%s/compare/%s...%s", c.baseURL, owner, repo, base, head)
+	var resp compareResponse
+	if err := c.getJSON(ctx, compareURL, &resp); err != nil {
+		return nil, fmt.Errorf("compare %s...%s: %w", ShortSHA(base), ShortSHA(head), err)
+	}
+
+	cmp := &Comparison{
+		Status:   resp.Status,
+		AheadBy:  resp.AheadBy,
+		BehindBy: resp.BehindBy,
+		Commits:  make([]Commit, 0, len(resp.Commits)),
+	}
+	for _, rc := range resp.Commits {
+		commit := Commit{
+			SHA:     rc.SHA,
+			Author:  rc.Commit.Author.Name,
+			Message: firstLine(rc.Commit.Message),
+		}
+		if rc.Author != nil && rc.Author.Login != "" {
+			commit.Author = rc.Author.Login
+		}
+		if t, err := time.Parse(time.RFC3339, rc.Commit.Author.Date); err == nil {
+			commit.Date = t
+		}
+		cmp.Commits = append(cmp.Commits, commit)
+	}
+	return cmp, nil
+}
+
 // PollCheckRun fetches a single check-run with ETag support for efficient polling.
 // When ETag is provided and server returns 304, result.NotModified will be true.
-func PollCheckRun(ctx context.Context, req PollCheckRunRequest) (*CheckRunResult, error) {
+func (c *Client) PollCheckRun(ctx context.Context, req PollCheckRunRequest) (*CheckRunResult, error) {
 	checkURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-runs?check_name=%s&per_page=1",
-		apiBase, req.Owner, req.Repo, req.SHA, url.QueryEscape(req.CheckName))
+		c.baseURL, req.Owner, req.Repo, req.SHA, url.QueryEscape(req.CheckName))
 
 	var resp checkRunsResponse
-	cond, err := getJSONConditional(ctx, checkURL, req.ETag, &resp)
+	cond, err := c.getJSONConditional(ctx, checkURL, req.ETag, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +214,7 @@ func PollCheckRun(ctx context.Context, req PollCheckRunRequest) (*CheckRunResult
 
 	if len(resp.CheckRuns) > 0 {
 		cr := resp.CheckRuns[0]
+		result.ID = cr.ID
 		result.Status = cr.Status
 		result.Conclusion = cr.Conclusion
 		if t, err := time.Parse(time.RFC3339, cr.CompletedAt); err == nil {
@@ -85,23 +225,110 @@ func PollCheckRun(ctx context.Context, req PollCheckRunRequest) (*CheckRunResult
 	return result, nil
 }
 
+// PollChecks fetches every check-run and check-suite on a commit.
+// The ETag applies to the first check-runs page: when it is unchanged nothing else is fetched.
+func (c *Client) PollChecks(ctx context.Context, req PollChecksRequest) (*ChecksResult, error) {
+	const perPage = 100
+	runsURL := func(page int) string {
+		return fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-runs?filter=latest&per_page=%d&page=%d",
+			c.baseURL, req.Owner, req.Repo, req.SHA, perPage, page)
+	}
+
+	var first checkRunsResponse
+	cond, err := c.getJSONConditional(ctx, runsURL(1), req.ETag, &first)
+	if err != nil {
+		return nil, err
+	}
+	result := &ChecksResult{ETag: cond.ETag, NotModified: cond.NotModified}
+	if cond.NotModified {
+		return result, nil
+	}
+
+	entries := first.CheckRuns
+	for page := 2; len(entries) < first.TotalCount; page++ {
+		var resp checkRunsResponse
+		if err := c.getJSON(ctx, runsURL(page), &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.CheckRuns) == 0 {
+			break
+		}
+		entries = append(entries, resp.CheckRuns...)
+	}
+	for _, e := range entries {
+		result.Runs = append(result.Runs, newCheckRun(e))
+	}
+
+	suitesURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-suites?per_page=%d", c.baseURL, req.Owner, req.Repo, req.SHA, perPage)
+	var suites checkSuitesResponse
+	if err := c.getJSON(ctx, suitesURL, &suites); err != nil {
+		return nil, err
+	}
+	for _, cs := range suites.CheckSuites {
+		result.Suites = append(result.Suites, CheckSuite{
+			ID:         cs.ID,
+			App:        cs.App.Name,
+			Status:     cs.Status,
+			Conclusion: cs.Conclusion,
+			RunCount:   cs.LatestCheckRunsCount,
+		})
+	}
+
+	return result, nil
+}
+
+// IsPassing reports whether a completed check-run conclusion counts as passing.
+func IsPassing(conclusion string) bool {
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return true
+	}
+	return false
+}
+
+func newCheckRun(e checkRunEntry) CheckRun {
+	cr := CheckRun{
+		ID:         e.ID,
+		Name:       e.Name,
+		Status:     e.Status,
+		Conclusion: e.Conclusion,
+		HTMLURL:    e.HTMLURL,
+	}
+	if t, err := time.Parse(time.RFC3339, e.StartedAt); err == nil {
+		cr.StartedAt = t
+	}
+	if t, err := time.Parse(time.RFC3339, e.CompletedAt); err == nil {
+		cr.CompletedAt = t
+	}
+	return cr
+}
+
 // FetchPRInfo fetches PR info, last commit details, and CI build status.
-// Uses 2-3 GitHub API calls (public, no token needed).
-// When skipCommitDetails is true, skips the commit details call (2 calls instead of 3).
-func FetchPRInfo(ctx context.Context, owner, repo string, prNumber int, buildCheckName string, skipCommitDetails bool) (*PRInfo, error) {
-	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", apiBase, owner, repo, prNumber)
+// With a token this is one GraphQL query; anonymous calls use 2-3 REST calls.
+// When skipCommitDetails is true, REST skips the commit details call (2 calls instead of 3).
+// The build is the check-run of the first of buildCheckNames found, see FindCheckRun.
+func (c *Client) FetchPRInfo(ctx context.Context, owner, repo string, prNumber int, buildCheckNames []string, skipCommitDetails bool) (*PRInfo, error) {
+	if c.token == "" {
+		return c.fetchPRInfoREST(ctx, owner, repo, prNumber, buildCheckNames, skipCommitDetails)
+	}
+	res := c.fetchPRInfoGraphQL(ctx, []PRRequest{{Owner: owner, Repo: repo, Number: prNumber, BuildCheckNames: buildCheckNames}})
+	return res[0].Info, res[0].Err
+}
+
+func (c *Client) fetchPRInfoREST(ctx context.Context, owner, repo string, prNumber int, buildCheckNames []string, skipCommitDetails bool) (*PRInfo, error) {
+	ctx, stale := withStaleTracker(ctx)
+	prURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, repo, prNumber)
 
 	var prResp pullRequestResponse
-	if err := getJSON(ctx, prURL, &prResp); err != nil {
+	if err := c.getJSON(ctx, prURL, &prResp); err != nil {
 		return nil, fmt.Errorf("fetch PR #%d: %w", prNumber, err)
 	}
 
 	info := &PRInfo{
-		Number:         prNumber,
-		Title:          prResp.Title,
-		URL:            prResp.HTMLURL,
-		HeadSHA:        prResp.Head.SHA,
-		BuildCheckName: buildCheckName,
+		Number:    prNumber,
+		Title:     prResp.Title,
+		URL:       prResp.HTMLURL,
+		HeadBuild: HeadBuild{HeadSHA: prResp.Head.SHA},
 	}
 	if t, err := time.Parse(time.RFC3339, prResp.UpdatedAt); err == nil {
 		info.UpdatedAt = t
@@ -112,22 +339,23 @@ func FetchPRInfo(ctx context.Context, owner, repo string, prNumber int, buildChe
 
 	if !skipCommitDetails {
 		g.Go(func() error {
-			c, err := fetchCommitInfo(ctx, owner, repo, info.HeadSHA)
+			commit, err := c.fetchCommitInfo(ctx, owner, repo, info.HeadSHA)
 			if err != nil {
 				return fmt.Errorf("fetch commit %s: %w", info.HeadSHA, err)
 			}
-			info.CommitAuthor = c.Author
-			info.CommitDate = c.Date
-			info.CommitMessage = c.Message
+			info.CommitAuthor = commit.Author
+			info.CommitDate = commit.Date
+			info.CommitMessage = commit.Message
 			return nil
 		})
 	}
 
 	g.Go(func() error {
-		cr, err := fetchCheckRun(ctx, owner, repo, info.HeadSHA, buildCheckName)
+		cr, name, err := c.FindCheckRun(ctx, owner, repo, info.HeadSHA, buildCheckNames)
 		if err != nil {
-			return fmt.Errorf("fetch check-run %q for %s: %w", buildCheckName, info.HeadSHA, err)
+			return err
 		}
+		info.BuildCheckName = name
 		info.BuildStatus = cr.Status
 		info.BuildConclusion = cr.Conclusion
 		info.BuildCompletedAt = cr.CompletedAt
@@ -138,5 +366,6 @@ func FetchPRInfo(ctx context.Context, owner, repo string, prNumber int, buildChe
 		return nil, err
 	}
 
+	info.CachedAt = stale.oldest()
 	return info, nil
 }
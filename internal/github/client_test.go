@@ -0,0 +1,414 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/fakeapi"
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+)
+
+var (
+	headCommit = fakeapi.Commit{
+		SHA:     "1111111111111111111111111111111111111111",
+		Author:  "Jane Doe",
+		Message: "Fix the thing\n\nLonger description.",
+		Date:    time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	buildDone = time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+)
+
+func newFakeGitHub(t *testing.T) *fakeapi.GitHub {
+	gh := fakeapi.NewGitHub(t)
+	gh.AddPR(fakeapi.PR{Owner: "deckhouse", Repo: "deckhouse", Number: 42, Title: "Fix the thing"}, headCommit)
+	gh.SetCheckRun(headCommit.SHA, fakeapi.CheckRun{ID: 7, Name: "Build FE", Status: "completed", Conclusion: "success", CompletedAt: buildDone})
+	return gh
+}
+
+func checkPRInfo(t *testing.T, info *github.PRInfo) {
+	t.Helper()
+	if info.Title != "Fix the thing" || info.HeadSHA != headCommit.SHA {
+		t.Errorf("PR = %q at %s, want %q at %s", info.Title, info.HeadSHA, "Fix the thing", headCommit.SHA)
+	}
+	if info.CommitAuthor != "Jane Doe" || info.CommitMessage != "Fix the thing" || !info.CommitDate.Equal(headCommit.Date) {
+		t.Errorf("commit = %q %q %s", info.CommitAuthor, info.CommitMessage, info.CommitDate)
+	}
+	if info.BuildStatus != "completed" || info.BuildConclusion != "success" || !info.BuildCompletedAt.Equal(buildDone) {
+		t.Errorf("build = %s/%s at %s, want completed/success at %s", info.BuildStatus, info.BuildConclusion, info.BuildCompletedAt, buildDone)
+	}
+}
+
+func TestFetchPRInfoAnonymous(t *testing.T) {
+	gh := newFakeGitHub(t)
+	c := github.NewClient(github.Options{BaseURL: gh.URL, UserAgent: "test-agent"})
+
+	info, err := c.FetchPRInfo(context.Background(), "deckhouse", "deckhouse", 42, []string{"Build FE"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPRInfo(t, info)
+
+	for _, r := range gh.Requests() {
+		if r.URL.Path == "/graphql" {
+			t.Error("anonymous client used GraphQL")
+		}
+		if got := r.Header.Get("User-Agent"); got != "test-agent" {
+			t.Errorf("User-Agent = %q, want test-agent", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+	}
+}
+
+func TestFetchPRInfoBatchGraphQL(t *testing.T) {
+	gh := newFakeGitHub(t)
+	c := github.NewClient(github.Options{BaseURL: gh.URL, Token: "secret"})
+
+	results := c.FetchPRInfoBatch(context.Background(), []github.PRRequest{
+		{Owner: "deckhouse", Repo: "deckhouse", Number: 42, BuildCheckNames: []string{"Build FE"}},
+		{Owner: "deckhouse", Repo: "deckhouse", Number: 404, BuildCheckNames: []string{"Build FE"}},
+	}, false)
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+	checkPRInfo(t, results[0].Info)
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "#404") {
+		t.Errorf("missing PR: err = %v, want an error naming #404", results[1].Err)
+	}
+
+	reqs := gh.Requests()
+	if len(reqs) != 1 || reqs[0].URL.Path != "/graphql" {
+		t.Fatalf("sent %d requests, want a single GraphQL query", len(reqs))
+	}
+	if got := reqs[0].Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestFetchPRInfoChecksGraphQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		runs       []fakeapi.CheckRun // added to the head after the passed build
+		wantState  string
+		wantChecks []string // name=status/conclusion
+	}{
+		{name: "all passed", wantState: "success", wantChecks: []string{"Build FE=completed/success"}},
+		{
+			name:       "one running",
+			runs:       []fakeapi.CheckRun{{ID: 8, Name: "e2e", Status: "in_progress"}},
+			wantState:  "pending",
+			wantChecks: []string{"Build FE=completed/success", "e2e=in_progress/"},
+		},
+		{
+			name: "failed over running",
+			runs: []fakeapi.CheckRun{
+				{ID: 8, Name: "lint", Status: "completed", Conclusion: "failure"},
+				{ID: 9, Name: "e2e", Status: "queued"},
+			},
+			wantState:  "failure",
+			wantChecks: []string{"Build FE=completed/success", "e2e=queued/", "lint=completed/failure"},
+		},
+		{
+			name: "re-run replaces the old run",
+			runs: []fakeapi.CheckRun{
+				{ID: 8, Name: "lint", Status: "completed", Conclusion: "failure"},
+				{ID: 9, Name: "lint", Status: "completed", Conclusion: "success"},
+			},
+			wantState:  "success",
+			wantChecks: []string{"Build FE=completed/success", "lint=completed/success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := newFakeGitHub(t)
+			for _, cr := range tt.runs {
+				gh.SetCheckRun(headCommit.SHA, cr)
+			}
+			c := github.NewClient(github.Options{BaseURL: gh.URL, Token: "secret"})
+
+			info, err := c.FetchPRInfo(context.Background(), "deckhouse", "deckhouse", 42, []string{"Build FE"}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPRInfo(t, info)
+			var checks []string
+			for _, cr := range info.Checks {
+				checks = append(checks, cr.Name+"="+cr.Status+"/"+cr.Conclusion)
+			}
+			if info.ChecksState != tt.wantState || strings.Join(checks, " ") != strings.Join(tt.wantChecks, " ") {
+				t.Errorf("checks = %s %v, want %s %v", info.ChecksState, checks, tt.wantState, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestFetchPRInfoCheckNameFallback(t *testing.T) {
+	for _, token := range []string{"", "secret"} {
+		gh := fakeapi.NewGitHub(t)
+		gh.AddPR(fakeapi.PR{Owner: "deckhouse", Repo: "deckhouse", Number: 42, Title: "Fix the thing"}, headCommit)
+		gh.SetCheckRun(headCommit.SHA, fakeapi.CheckRun{ID: 7, Name: "Build FE (legacy)", Status: "completed", Conclusion: "success", CompletedAt: buildDone})
+		c := github.NewClient(github.Options{BaseURL: gh.URL, Token: token})
+
+		info, err := c.FetchPRInfo(context.Background(), "deckhouse", "deckhouse", 42, []string{"Build FE", "Build FE (legacy)"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.BuildCheckName != "Build FE (legacy)" || info.BuildConclusion != "success" {
+			t.Errorf("token %q: build = %q %s, want the legacy check passed", token, info.BuildCheckName, info.BuildConclusion)
+		}
+	}
+}
+
+func TestPollCheckRunETag(t *testing.T) {
+	gh := newFakeGitHub(t)
+	gh.SetCheckRun(headCommit.SHA, fakeapi.CheckRun{ID: 7, Name: "Build FE", Status: "in_progress"})
+	c := github.NewClient(github.Options{BaseURL: gh.URL})
+	ctx := context.Background()
+	req := github.PollCheckRunRequest{Owner: "deckhouse", Repo: "deckhouse", SHA: headCommit.SHA, CheckName: "Build FE"}
+
+	first, err := c.PollCheckRun(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != 7 || first.Status != "in_progress" || first.ETag == "" {
+		t.Fatalf("first poll = %+v", first)
+	}
+
+	req.ETag = first.ETag
+	second, err := c.PollCheckRun(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.NotModified {
+		t.Errorf("unchanged check-run: NotModified = false")
+	}
+
+	gh.SetCheckRun(headCommit.SHA, fakeapi.CheckRun{ID: 7, Name: "Build FE", Status: "completed", Conclusion: "failure", CompletedAt: buildDone})
+	third, err := c.PollCheckRun(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.NotModified || third.Status != "completed" || third.Conclusion != "failure" {
+		t.Errorf("changed check-run = %+v", third)
+	}
+}
+
+func TestFetchFailure(t *testing.T) {
+	gh := newFakeGitHub(t)
+	gh.SetCheckRun(headCommit.SHA, fakeapi.CheckRun{
+		ID: 7, Name: "Build FE", Status: "completed", Conclusion: "failure", RunID: 99,
+		FailedSteps: []string{"Build image"},
+		Annotations: []string{"  compile error in main.go\nsee the log for details\n", "Process completed with exit code 1."},
+	})
+	c := github.NewClient(github.Options{BaseURL: gh.URL})
+
+	f, err := c.FetchFailure(context.Background(), "deckhouse", "deckhouse", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, a := range f.Annotations {
+		messages = append(messages, a.Message)
+	}
+	if want := []string{"compile error in main.go", "Process completed with exit code 1."}; strings.Join(messages, "|") != strings.Join(want, "|") {
+		t.Errorf("annotations = %q, want %q", messages, want)
+	}
+	if f.RunID != 99 || len(f.FailedSteps) != 1 || f.FailedSteps[0] != "Build image" {
+		t.Errorf("job = run %d, failed steps %v; want run 99, [Build image]", f.RunID, f.FailedSteps)
+	}
+}
+
+func TestRerunFailedJobs(t *testing.T) {
+	gh := newFakeGitHub(t)
+	gh.SetCheckRun(headCommit.SHA, fakeapi.CheckRun{ID: 7, Name: "Build FE", Status: "completed", Conclusion: "failure", RunID: 99})
+	ctx := context.Background()
+
+	anon := github.NewClient(github.Options{BaseURL: gh.URL})
+	if err := anon.RerunFailedJobs(ctx, "deckhouse", "deckhouse", 99); err == nil {
+		t.Error("anonymous re-run succeeded, want an error")
+	}
+
+	c := github.NewClient(github.Options{BaseURL: gh.URL, Token: "secret"})
+	runID, err := c.FetchWorkflowRunID(ctx, "deckhouse", "deckhouse", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RerunFailedJobs(ctx, "deckhouse", "deckhouse", runID); err != nil {
+		t.Fatal(err)
+	}
+	if got := gh.Reruns(); len(got) != 1 || got[0] != 99 {
+		t.Errorf("re-runs = %v, want [99]", got)
+	}
+}
+
+func TestCacheServesFreshAndStale(t *testing.T) {
+	gh := newFakeGitHub(t)
+	c := github.NewClient(github.Options{BaseURL: gh.URL})
+	if err := c.EnableCache(t.TempDir(), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := c.FetchHeadSHA(ctx, "deckhouse", "deckhouse", 42); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FetchHeadSHA(ctx, "deckhouse", "deckhouse", 42); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(gh.Requests()); n != 1 {
+		t.Errorf("fresh entry: sent %d requests, want 1", n)
+	}
+
+	// With GitHub gone, the expired entry is better than nothing.
+	c.SetCacheMaxAge(0)
+	gh.Close()
+	info, err := c.FetchPRInfo(ctx, "deckhouse", "deckhouse", 42, []string{"Build FE"}, true)
+	if err == nil {
+		t.Fatalf("uncached check-run served: %+v", info)
+	}
+	sha, err := c.FetchHeadSHA(ctx, "deckhouse", "deckhouse", 42)
+	if err != nil || sha != headCommit.SHA {
+		t.Errorf("stale head = %q, %v; want %s", sha, err, headCommit.SHA)
+	}
+}
+
+func TestFetchReleaseInfo(t *testing.T) {
+	// Listed newest first: a draft and a pre-release, 120 releases of v1.67,
+	// v1.66.10 to v1.66.0 with v1.66.10 published before v1.66.9, and 150 of
+	// v1.65. v1.66.0 is on the second page of 100, v1.65 starts the third.
+	gh := fakeapi.NewGitHub(t)
+	add := func(minor int, patches ...int) {
+		for _, p := range patches {
+			gh.AddRelease("deckhouse", "deckhouse", fakeapi.Release{Tag: fmt.Sprintf("v1.%d.%d", minor, p), PublishedAt: buildDone})
+		}
+	}
+	for p := range 150 {
+		add(65, p)
+	}
+	add(66, 0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 9)
+	for p := range 120 {
+		add(67, p)
+	}
+	gh.AddRelease("deckhouse", "deckhouse", fakeapi.Release{Tag: "v1.66.12", Prerelease: true})
+	gh.AddRelease("deckhouse", "deckhouse", fakeapi.Release{Tag: "v1.66.11", Draft: true})
+
+	tests := []struct {
+		tag        string
+		wantLatest string
+		wantURL    bool // the release of tag is found
+		wantPages  int
+	}{
+		{tag: "v1.66.3", wantLatest: "v1.66.10", wantURL: true, wantPages: 2},
+		{tag: "v1.66.10", wantLatest: "v1.66.10", wantURL: true, wantPages: 2},
+		{tag: "v1.66.13", wantLatest: "v1.66.13", wantPages: 2},
+		{tag: "v1.65.7", wantLatest: "v1.65.149", wantURL: true, wantPages: 3},
+		{tag: "v1.64.0", wantLatest: "v1.64.0", wantPages: 3},
+	}
+
+	for _, cached := range []bool{false, true} {
+		start := len(gh.Requests())
+		c := github.NewClient(github.Options{BaseURL: gh.URL})
+		if cached {
+			if err := c.EnableCache(t.TempDir(), time.Hour); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, tt := range tests {
+			before := len(gh.Requests())
+			info, err := c.FetchReleaseInfo(context.Background(), "deckhouse", "deckhouse", tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.LatestPatch != tt.wantLatest || (info.URL != "") != tt.wantURL {
+				t.Errorf("cached %v, %s: latest patch %s, URL %q; want %s", cached, tt.tag, info.LatestPatch, info.URL, tt.wantLatest)
+			}
+			if info.LatestPatch == tt.tag && info.LatestPatchURL != info.URL {
+				t.Errorf("cached %v, %s: latest patch URL %q, want the release URL %q", cached, tt.tag, info.LatestPatchURL, info.URL)
+			}
+			if pages := len(gh.Requests()) - before; !cached && pages != tt.wantPages {
+				t.Errorf("%s: fetched %d pages, want %d", tt.tag, pages, tt.wantPages)
+			}
+		}
+		// Cached pages stay fresh for an hour, so each is fetched once.
+		if n := len(gh.Requests()) - start; cached && n != 3 {
+			t.Errorf("cached: fetched %d pages, want 3", n)
+		}
+	}
+}
+
+func TestResponseErrors(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		body    string
+		check   func(t *testing.T, err error)
+	}{
+		{
+			name:    "quota exhausted",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)},
+			body:    `{"message": "API rate limit exceeded"}`,
+			check: func(t *testing.T, err error) {
+				var rl *github.RateLimitError
+				if !errors.As(err, &rl) || rl.Secondary || !rl.RetryAt.Equal(reset) {
+					t.Errorf("err = %v, want a primary rate limit until %s", err, reset)
+				}
+			},
+		},
+		{
+			name:    "secondary limit",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"Retry-After": "60"},
+			body:    `{"message": "You have exceeded a secondary rate limit"}`,
+			check: func(t *testing.T, err error) {
+				var rl *github.RateLimitError
+				if !errors.As(err, &rl) || !rl.Secondary {
+					t.Errorf("err = %v, want a secondary rate limit", err)
+				}
+			},
+		},
+		{
+			name:   "permission denied",
+			status: http.StatusForbidden,
+			body:   `{"message": "Resource not accessible by integration"}`,
+			check: func(t *testing.T, err error) {
+				var rl *github.RateLimitError
+				if errors.As(err, &rl) || err == nil || !strings.Contains(err.Error(), "permission denied") {
+					t.Errorf("err = %v, want a permission error", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := github.NewClient(github.Options{BaseURL: srv.URL, HTTPClient: srv.Client()})
+			_, err := c.FetchHeadSHA(context.Background(), "deckhouse", "deckhouse", 42)
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxAnnotations caps the annotations fetched for a failed check-run.
+const maxAnnotations = 50
+
+type checkRunDetailResponse struct {
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+	Output  struct {
+		Title   string `json:"title"`
+		Summary string `json:"summary"`
+	} `json:"output"`
+}
+
+type annotationEntry struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+type jobResponse struct {
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+	RunID   int64  `json:"run_id"`
+	Steps   []struct {
+		Number     int    `json:"number"`
+		Name       string `json:"name"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"steps"`
+}
+
+// Failure describes why a check-run failed.
+type Failure struct {
+	Name        string
+	URL         string // link to the job log (or the check-run page for non-Actions checks)
+	Title       string // check-run output title
+	Summary     string // check-run output summary, may be markdown
+	Annotations []Annotation
+	FailedSteps []string // names of failed GitHub Actions job steps
+	RunID       int64    // workflow run ID, 0 if the check is not an Actions job
+}
+
+// Annotation is a check-run annotation, usually a compiler or linter error.
+type Annotation struct {
+	Path    string
+	Line    int
+	Level   string // "failure", "warning", "notice"
+	Message string // first line only, prefixed with the title if it adds one
+}
+
+// FetchFailure fetches the output, error annotations and failed job steps of a
+// check-run. For GitHub Actions the check-run ID is also the job ID; the job
+// lookup is best-effort since other apps create check-runs too.
+func (c *Client) FetchFailure(ctx context.Context, owner, repo string, checkRunID int64) (*Failure, error) {
+	base := fmt.Sprintf("%s/repos/%s/%s", c.baseURL, owner, repo)
+
+	var cr checkRunDetailResponse
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/check-runs/%d", base, checkRunID), &cr); err != nil {
+		return nil, fmt.Errorf("fetch check-run %d: %w", checkRunID, err)
+	}
+	f := &Failure{
+		Name:    cr.Name,
+		URL:     cr.HTMLURL,
+		Title:   cr.Output.Title,
+		Summary: strings.TrimSpace(cr.Output.Summary),
+	}
+
+	var annotations []annotationEntry
+	annURL := fmt.Sprintf("%s/check-runs/%d/annotations?per_page=%d", base, checkRunID, maxAnnotations)
+	if err := c.getJSON(ctx, annURL, &annotations); err != nil {
+		return nil, fmt.Errorf("fetch annotations: %w", err)
+	}
+	for _, a := range annotations {
+		msg := strings.TrimSpace(a.Message)
+		if a.Title != "" && !strings.Contains(msg, a.Title) {
+			msg = a.Title + ": " + msg
+		}
+		f.Annotations = append(f.Annotations, Annotation{Path: a.Path, Line: a.StartLine, Level: a.AnnotationLevel, Message: firstLine(msg)})
+	}
+
+	var job jobResponse
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/actions/jobs/%d", base, checkRunID), &job); err == nil {
+		f.RunID = job.RunID
+		if job.HTMLURL != "" {
+			f.URL = job.HTMLURL
+		}
+		for _, s := range job.Steps {
+			if s.Status == "completed" && !IsPassing(s.Conclusion) {
+				f.FailedSteps = append(f.FailedSteps, s.Name)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// FetchJobLogTail returns the last n lines of a GitHub Actions job log with the
+// per-line timestamps removed. The logs API requires a token.
+func (c *Client) FetchJobLogTail(ctx context.Context, owner, repo string, jobID int64, n int) (lines []string, err error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("job logs require a GitHub token")
+	}
+
+	logURL := fmt.Sprintf("%s/repos/%s/%s/actions/jobs/%d/logs", c.baseURL, owner, repo, jobID)
+	req, err := c.newRequest(ctx, "GET", logURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API redirects to a signed blob URL; the client drops Authorization
+	// on the cross-host redirect.
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close response body: %w", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch job log: %w", responseError(resp))
+	}
+
+	ring := make([]string, 0, n)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := stripLogTimestamp(scanner.Text())
+		if len(ring) == n {
+			ring = append(ring[1:], line)
+		} else {
+			ring = append(ring, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read job log: %w", err)
+	}
+	return ring, nil
+}
+
+// stripLogTimestamp removes the "2024-01-02T15:04:05.1234567Z " prefix Actions adds to every log line.
+func stripLogTimestamp(line string) string {
+	line = strings.TrimPrefix(line, "\ufeff")
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return line
+	}
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		return line
+	}
+	return rest
+}
@@ -0,0 +1,334 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBatchPRs caps the pull requests fetched per GraphQL query to keep the
+// query cost well below the node limit.
+const maxBatchPRs = 20
+
+// PRRequest identifies one PR build for FetchPRInfoBatch.
+type PRRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+	// BuildCheckNames are candidate build check names, first found wins,
+	// e.g. ["Build FE"].
+	BuildCheckNames []string
+}
+
+// PRResult is the outcome of one PRRequest.
+type PRResult struct {
+	Info *PRInfo
+	Err  error
+}
+
+// FetchPRInfoBatch fetches several PRs at once. With a token every batch of
+// up to maxBatchPRs PRs is a single GraphQL query that includes the head commit,
+// the build check-run and the state of all checks. Anonymous calls fall back to
+// FetchPRInfo over REST (GraphQL requires a token), which leaves
+// PRInfo.ChecksState and Checks empty. Results are in the order of reqs.
+func (c *Client) FetchPRInfoBatch(ctx context.Context, reqs []PRRequest, skipCommitDetails bool) []PRResult {
+	results := make([]PRResult, len(reqs))
+
+	var wg sync.WaitGroup
+	if c.token == "" {
+		for i, r := range reqs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				info, err := c.fetchPRInfoREST(ctx, r.Owner, r.Repo, r.Number, r.BuildCheckNames, skipCommitDetails)
+				results[i] = PRResult{Info: info, Err: err}
+			}()
+		}
+		wg.Wait()
+		return results
+	}
+
+	for start := 0; start < len(reqs); start += maxBatchPRs {
+		end := min(start+maxBatchPRs, len(reqs))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			copy(results[start:end], c.fetchPRInfoGraphQL(ctx, reqs[start:end]))
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// prFields selects everything PRInfo needs. The build check is looked up in
+// every check suite of the head commit: there is one suite per workflow.
+// %[2]s is one checkRunFields per candidate check name. At most 50 suites of
+// 100 runs keep a full batch far below the node limit.
+const prFields = `title url updatedAt
+      commits(last: 1) {
+        nodes {
+          commit {
+            oid message
+            author { name date }
+            statusCheckRollup { state }
+            checkSuites(first: 50) {
+              nodes {
+                runs: checkRuns(first: 100, filterBy: {checkType: LATEST}) {
+                  nodes { databaseId name status conclusion startedAt completedAt url }
+                }%[2]s
+              }
+            }
+          }
+        }
+      }`
+
+// checkRunFields selects the latest check-run named $cI_J as kJ.
+const checkRunFields = `
+                k%[2]d: checkRuns(first: 1, filterBy: {checkName: $c%[1]d_%[2]d, checkType: LATEST}) {
+                  nodes { databaseId status conclusion completedAt }
+                }`
+
+type graphQLCheckRuns struct {
+	Nodes []struct {
+		DatabaseID  int64  `json:"databaseId"`
+		Name        string `json:"name"`
+		Status      string `json:"status"`
+		Conclusion  string `json:"conclusion"`
+		StartedAt   string `json:"startedAt"`
+		CompletedAt string `json:"completedAt"`
+		URL         string `json:"url"`
+	} `json:"nodes"`
+}
+
+type graphQLPR struct {
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	UpdatedAt string `json:"updatedAt"`
+	Commits   struct {
+		Nodes []struct {
+			Commit struct {
+				OID     string `json:"oid"`
+				Message string `json:"message"`
+				Author  struct {
+					Name string `json:"name"`
+					Date string `json:"date"`
+				} `json:"author"`
+				// StatusCheckRollup is null while the commit has no checks.
+				StatusCheckRollup *struct {
+					State string `json:"state"`
+				} `json:"statusCheckRollup"`
+				CheckSuites struct {
+					// All check-runs as "runs", the build candidates by
+					// alias "kJ", see checkRunFields.
+					Nodes []map[string]graphQLCheckRuns `json:"nodes"`
+				} `json:"checkSuites"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+type graphQLResponse struct {
+	Data map[string]*struct {
+		PullRequest *graphQLPR `json:"pullRequest"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+		Path    []any  `json:"path"`
+	} `json:"errors"`
+}
+
+// fetchPRInfoGraphQL fetches reqs in one query, aliasing each PR as pN.
+func (c *Client) fetchPRInfoGraphQL(ctx context.Context, reqs []PRRequest) []PRResult {
+	results := make([]PRResult, len(reqs))
+
+	var params, fields []string
+	vars := make(map[string]any, 4*len(reqs))
+	for i, r := range reqs {
+		params = append(params, fmt.Sprintf("$o%[1]d: String!, $r%[1]d: String!, $n%[1]d: Int!", i))
+		var runs strings.Builder
+		for j, name := range r.BuildCheckNames {
+			params = append(params, fmt.Sprintf("$c%d_%d: String!", i, j))
+			fmt.Fprintf(&runs, checkRunFields, i, j)
+			vars[fmt.Sprintf("c%d_%d", i, j)] = name
+		}
+		fields = append(fields, fmt.Sprintf("  p%[1]d: repository(owner: $o%[1]d, name: $r%[1]d) {\n    pullRequest(number: $n%[1]d) {\n      %[2]s\n    }\n  }",
+			i, fmt.Sprintf(prFields, i, runs.String())))
+		vars[fmt.Sprintf("o%d", i)] = r.Owner
+		vars[fmt.Sprintf("r%d", i)] = r.Repo
+		vars[fmt.Sprintf("n%d", i)] = r.Number
+	}
+	query := fmt.Sprintf("query(%s) {\n%s\n}", strings.Join(params, ", "), strings.Join(fields, "\n"))
+
+	ctx, stale := withStaleTracker(ctx)
+	var resp graphQLResponse
+	if err := c.postGraphQL(ctx, query, vars, &resp); err != nil {
+		for i, r := range reqs {
+			results[i].Err = fmt.Errorf("fetch PR #%d: %w", r.Number, err)
+		}
+		return results
+	}
+
+	// Errors are per field, e.g. NOT_FOUND for one PR; the rest still has data.
+	fieldErrs := make(map[string]string)
+	for _, e := range resp.Errors {
+		if len(e.Path) > 0 {
+			if alias, ok := e.Path[0].(string); ok {
+				fieldErrs[alias] = e.Message
+				continue
+			}
+		}
+		for i := range reqs {
+			fieldErrs[fmt.Sprintf("p%d", i)] = e.Message
+		}
+	}
+
+	for i, r := range reqs {
+		alias := fmt.Sprintf("p%d", i)
+		repoData := resp.Data[alias]
+		switch {
+		case fieldErrs[alias] != "":
+			results[i].Err = fmt.Errorf("fetch PR #%d: %s", r.Number, fieldErrs[alias])
+		case repoData == nil || repoData.PullRequest == nil:
+			results[i].Err = fmt.Errorf("fetch PR #%d: not found", r.Number)
+		default:
+			results[i].Info = newPRInfoFromGraphQL(r, repoData.PullRequest)
+			results[i].Info.CachedAt = stale.oldest()
+		}
+	}
+	return results
+}
+
+func newPRInfoFromGraphQL(r PRRequest, pr *graphQLPR) *PRInfo {
+	info := &PRInfo{
+		Number: r.Number,
+		Title:  pr.Title,
+		URL:    pr.URL,
+	}
+	if len(r.BuildCheckNames) > 0 {
+		info.BuildCheckName = r.BuildCheckNames[0]
+	}
+	if t, err := time.Parse(time.RFC3339, pr.UpdatedAt); err == nil {
+		info.UpdatedAt = t
+	}
+	if len(pr.Commits.Nodes) == 0 {
+		return info
+	}
+
+	c := pr.Commits.Nodes[0].Commit
+	info.HeadSHA = c.OID
+	info.CommitAuthor = c.Author.Name
+	info.CommitMessage = firstLine(c.Message)
+	if t, err := time.Parse(time.RFC3339, c.Author.Date); err == nil {
+		info.CommitDate = t
+	}
+
+	// The first candidate name with a check-run wins. A re-run workflow may
+	// leave the check in several suites; the newest wins.
+	for j, name := range r.BuildCheckNames {
+		var newest int64
+		for _, suite := range c.CheckSuites.Nodes {
+			for _, cr := range suite[fmt.Sprintf("k%d", j)].Nodes {
+				if cr.DatabaseID < newest {
+					continue
+				}
+				newest = cr.DatabaseID
+				info.BuildCheckName = name
+				// GraphQL enums are upper case; REST values are lower case.
+				info.BuildStatus = strings.ToLower(cr.Status)
+				info.BuildConclusion = strings.ToLower(cr.Conclusion)
+				info.BuildCompletedAt = time.Time{}
+				if t, err := time.Parse(time.RFC3339, cr.CompletedAt); err == nil {
+					info.BuildCompletedAt = t
+				}
+			}
+		}
+		if newest != 0 {
+			break
+		}
+	}
+
+	if c.StatusCheckRollup != nil {
+		info.ChecksState = strings.ToLower(c.StatusCheckRollup.State)
+	}
+	for _, suite := range c.CheckSuites.Nodes {
+		for _, n := range suite["runs"].Nodes {
+			cr := CheckRun{
+				ID:         n.DatabaseID,
+				Name:       n.Name,
+				Status:     strings.ToLower(n.Status),
+				Conclusion: strings.ToLower(n.Conclusion),
+				HTMLURL:    n.URL,
+			}
+			if t, err := time.Parse(time.RFC3339, n.StartedAt); err == nil {
+				cr.StartedAt = t
+			}
+			if t, err := time.Parse(time.RFC3339, n.CompletedAt); err == nil {
+				cr.CompletedAt = t
+			}
+			info.Checks = append(info.Checks, cr)
+		}
+	}
+	sort.Slice(info.Checks, func(i, j int) bool { return info.Checks[i].Name < info.Checks[j].Name })
+	return info
+}
+
+// postGraphQL runs a GraphQL query through the response cache, if enabled.
+// GraphQL has no ETags, so entries are only used while fresh or when GitHub
+// cannot answer.
+func (c *Client) postGraphQL(ctx context.Context, query string, vars map[string]any, target any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	if c.cache == nil {
+		return c.doGraphQL(ctx, body, target)
+	}
+
+	key := "POST " + c.baseURL + "/graphql\n" + string(body)
+	entry := c.cache.load(key)
+	if entry != nil && c.cache.fresh(entry) {
+		return json.Unmarshal(entry.Body, target)
+	}
+
+	var raw json.RawMessage
+	if err := c.doGraphQL(ctx, body, &raw); err != nil {
+		if entry == nil || !servesStale(err) {
+			return err
+		}
+		markStale(ctx, entry.FetchedAt)
+		return json.Unmarshal(entry.Body, target)
+	}
+	c.cache.store(key, &cacheEntry{FetchedAt: time.Now(), Body: raw})
+	return json.Unmarshal(raw, target)
+}
+
+// doGraphQL posts a query. GraphQL reports most failures in the response
+// body, so only transport and HTTP errors are returned here.
+func (c *Client) doGraphQL(ctx context.Context, body []byte, target any) (err error) {
+	req, err := c.newRequest(ctx, "POST", c.baseURL+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close response body: %w", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GraphQL: %w", responseError(resp))
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
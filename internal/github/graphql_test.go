@@ -0,0 +1,53 @@
+package github
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNewPRInfoFromGraphQL(t *testing.T) {
+	const body = `{
+  "title": "Fix the thing", "url": "https://github.com/deckhouse/deckhouse/pull/42", "updatedAt": "2026-03-01T11:00:00Z",
+  "commits": {"nodes": [{"commit": {
+    "oid": "1111111111111111111111111111111111111111", "message": "Fix the thing\n\nLonger description.",
+    "author": {"name": "Jane Doe", "date": "2026-03-01T10:00:00Z"},
+    "statusCheckRollup": {"state": "FAILURE"},
+    "checkSuites": {"nodes": [
+      {
+        "runs": {"nodes": [
+          {"databaseId": 7, "name": "Build FE", "status": "COMPLETED", "conclusion": "SUCCESS", "completedAt": "2026-03-01T10:30:00Z"},
+          {"databaseId": 8, "name": "lint", "status": "COMPLETED", "conclusion": "FAILURE"}
+        ]},
+        "k0": {"nodes": [{"databaseId": 7, "status": "COMPLETED", "conclusion": "SUCCESS", "completedAt": "2026-03-01T10:30:00Z"}]}
+      },
+      {
+        "runs": {"nodes": [{"databaseId": 9, "name": "e2e", "status": "IN_PROGRESS"}]},
+        "k0": {"nodes": []}
+      }
+    ]}
+  }}]}
+}`
+	var pr graphQLPR
+	if err := json.Unmarshal([]byte(body), &pr); err != nil {
+		t.Fatal(err)
+	}
+
+	info := newPRInfoFromGraphQL(PRRequest{Owner: "deckhouse", Repo: "deckhouse", Number: 42, BuildCheckNames: []string{"Build FE"}}, &pr)
+	if info.HeadSHA != "1111111111111111111111111111111111111111" || info.CommitMessage != "Fix the thing" {
+		t.Errorf("head = %s %q", info.HeadSHA, info.CommitMessage)
+	}
+	if info.BuildStatus != "completed" || info.BuildConclusion != "success" || info.BuildCompletedAt.IsZero() {
+		t.Errorf("build = %s/%s at %s, want completed/success", info.BuildStatus, info.BuildConclusion, info.BuildCompletedAt)
+	}
+	if info.ChecksState != "failure" {
+		t.Errorf("ChecksState = %q, want failure", info.ChecksState)
+	}
+	var names []string
+	for _, cr := range info.Checks {
+		names = append(names, cr.Name+"="+cr.Status+"/"+cr.Conclusion)
+	}
+	if want := []string{"Build FE=completed/success", "e2e=in_progress/", "lint=completed/failure"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Checks = %v, want %v", names, want)
+	}
+}
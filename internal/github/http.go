@@ -4,37 +4,133 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 )
 
-// githubToken is read once at init time from the environment.
-var githubToken = os.Getenv("GITHUB_TOKEN")
+// newRequest builds a GitHub API request with the standard headers and the token, if any.
+func (c *Client) newRequest(ctx context.Context, method, reqURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// post sends a bodyless POST and expects wantStatus.
+func (c *Client) post(ctx context.Context, reqURL string, wantStatus int) (err error) {
+	if c.token == "" {
+		return fmt.Errorf("a GitHub token is required")
+	}
+	req, err := c.newRequest(ctx, "POST", reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close response body: %w", closeErr)
+		}
+	}()
 
-func getJSON(ctx context.Context, url string, target any) error {
-	_, err := getJSONConditional(ctx, url, "", target)
+	if resp.StatusCode != wantStatus {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// httpError is a failed API response other than a rate limit.
+type httpError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *httpError) Error() string {
+	return e.Message
+}
+
+// getJSON performs a GET through the response cache, if enabled: fresh entries
+// are used as is, older ones revalidated with their ETag, and served stale
+// (recorded in the context's staleTracker) when GitHub cannot answer.
+func (c *Client) getJSON(ctx context.Context, url string, target any) error {
+	_, err := c.getJSONPage(ctx, url, target)
 	return err
 }
 
+// getJSONPage is getJSON for paginated lists. It also returns the URL of the
+// next page from the Link header, empty on the last page.
+func (c *Client) getJSONPage(ctx context.Context, url string, target any) (next string, err error) {
+	if c.cache == nil {
+		cond, err := c.getJSONConditional(ctx, url, "", target)
+		return c.nextPage(cond.Link), err
+	}
+
+	entry := c.cache.load(url)
+	if entry != nil && c.cache.fresh(entry) {
+		return c.nextPage(entry.Link), json.Unmarshal(entry.Body, target)
+	}
+	etag := ""
+	if entry != nil {
+		etag = entry.ETag
+	}
+
+	var body json.RawMessage
+	cond, err := c.getJSONConditional(ctx, url, etag, &body)
+	switch {
+	case err != nil:
+		if entry == nil || !servesStale(err) {
+			return "", err
+		}
+		markStale(ctx, entry.FetchedAt)
+	case cond.NotModified:
+		entry.FetchedAt = time.Now()
+		c.cache.store(url, entry)
+	default:
+		entry = &cacheEntry{ETag: cond.ETag, Link: cond.Link, FetchedAt: time.Now(), Body: body}
+		c.cache.store(url, entry)
+	}
+	return c.nextPage(entry.Link), json.Unmarshal(entry.Body, target)
+}
+
+// nextPage returns the rel="next" target of a Link header. Only API URLs are
+// followed, so the token is never sent elsewhere.
+func (c *Client) nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		next := strings.Trim(strings.TrimSpace(target), "<>")
+		if strings.HasPrefix(next, c.baseURL+"/") {
+			return next
+		}
+	}
+	return ""
+}
+
 // getJSONConditional performs a GET with optional If-None-Match header for ETag support.
-func getJSONConditional(ctx context.Context, reqURL string, etag string, target any) (result conditionalResult, err error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+func (c *Client) getJSONConditional(ctx context.Context, reqURL string, etag string, target any) (result conditionalResult, err error) {
+	req, err := c.newRequest(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return conditionalResult{}, err
 	}
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-	if githubToken != "" {
-		req.Header.Set("Authorization", "Bearer "+githubToken)
-	}
 	if etag != "" {
 		req.Header.Set("If-None-Match", etag)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return conditionalResult{}, err
 	}
@@ -44,24 +140,14 @@ func getJSONConditional(ctx context.Context, reqURL string, etag string, target
 		}
 	}()
 
-	result = conditionalResult{ETag: resp.Header.Get("ETag")}
+	result = conditionalResult{ETag: resp.Header.Get("ETag"), Link: resp.Header.Get("Link")}
 
 	switch {
 	case resp.StatusCode == http.StatusNotModified:
 		result.NotModified = true
 		return result, nil
-	case resp.StatusCode == http.StatusForbidden:
-		if resetStr := resp.Header.Get("X-RateLimit-Reset"); resetStr != "" {
-			if resetUnix, err := strconv.ParseInt(resetStr, 10, 64); err == nil {
-				wait := time.Until(time.Unix(resetUnix, 0)).Truncate(time.Second)
-				if wait > 0 {
-					return conditionalResult{}, fmt.Errorf("rate limited (resets in %s)", wait)
-				}
-			}
-		}
-		return conditionalResult{}, fmt.Errorf("rate limited (HTTP 403)")
 	case resp.StatusCode != http.StatusOK:
-		return conditionalResult{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+		return conditionalResult{}, responseError(resp)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
@@ -76,3 +162,11 @@ func firstLine(s string) string {
 	}
 	return s
 }
+
+// ShortSHA abbreviates a commit SHA for display.
+func ShortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,147 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secondaryLimitWait is how long to back off after a secondary rate limit
+// without Retry-After; GitHub asks to wait at least a minute.
+const secondaryLimitWait = time.Minute
+
+// RateLimit is the quota state of one API resource ("core", "graphql", ...)
+// as reported by the last response.
+type RateLimit struct {
+	Resource  string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	UpdatedAt time.Time
+}
+
+// RateLimits returns the last known quota of every resource used so far.
+func (c *Client) RateLimits() []RateLimit {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	out := make([]RateLimit, 0, len(c.rateLimits))
+	for _, rl := range c.rateLimits {
+		out = append(out, rl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Resource < out[j].Resource })
+	return out
+}
+
+// CoreRateLimit returns the last known REST quota; ok is false before the
+// first response.
+func (c *Client) CoreRateLimit() (rl RateLimit, ok bool) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	rl, ok = c.rateLimits["core"]
+	return rl, ok
+}
+
+// recordRateLimit stores the X-RateLimit-* headers of resp.
+func (c *Client) recordRateLimit(resp *http.Response) {
+	limit, err1 := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, err2 := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err1 != nil || err2 != nil {
+		return
+	}
+	rl := RateLimit{
+		Resource:  resp.Header.Get("X-RateLimit-Resource"),
+		Limit:     limit,
+		Remaining: remaining,
+		UpdatedAt: time.Now(),
+	}
+	if rl.Resource == "" {
+		rl.Resource = "core"
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+
+	c.rateMu.Lock()
+	c.rateLimits[rl.Resource] = rl
+	c.rateMu.Unlock()
+}
+
+// RateLimitError means GitHub refused a request because a rate limit was hit.
+type RateLimitError struct {
+	Secondary bool      // secondary (abuse) limit rather than the hourly quota
+	RetryAt   time.Time // when the request may be retried
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limited"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	if wait := e.Wait().Truncate(time.Second); wait > 0 {
+		return fmt.Sprintf("%s (resets in %s)", kind, wait)
+	}
+	return kind
+}
+
+// Wait returns how long to wait before retrying.
+func (e *RateLimitError) Wait() time.Duration {
+	return max(time.Until(e.RetryAt), 0)
+}
+
+// responseError turns a failed response into an error. 403 and 429 are told
+// apart by their headers and message: rate limits become *RateLimitError,
+// anything else a permission error.
+func responseError(resp *http.Response) error {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(body, &apiErr)
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		if rl := rateLimitError(resp, apiErr.Message); rl != nil {
+			return rl
+		}
+	}
+
+	msg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+	if apiErr.Message != "" {
+		msg += ": " + apiErr.Message
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		msg = "permission denied (" + msg + ")"
+	}
+	return &httpError{StatusCode: resp.StatusCode, Message: msg}
+}
+
+func rateLimitError(resp *http.Response, message string) *RateLimitError {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return &RateLimitError{Secondary: true, RetryAt: time.Now().Add(time.Duration(secs) * time.Second)}
+	}
+	if strings.Contains(strings.ToLower(message), "secondary rate limit") {
+		return &RateLimitError{Secondary: true, RetryAt: time.Now().Add(secondaryLimitWait)}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.StatusCode == http.StatusTooManyRequests {
+		rl := &RateLimitError{RetryAt: time.Now().Add(secondaryLimitWait)}
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			rl.RetryAt = time.Unix(reset, 0)
+		}
+		return rl
+	}
+	return nil
+}
+
+// do sends req and records the rate-limit headers of the response.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.recordRateLimit(resp)
+	return resp, nil
+}
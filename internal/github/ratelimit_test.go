@@ -0,0 +1,83 @@
+package github
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseError(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	tests := []struct {
+		name          string
+		status        int
+		headers       map[string]string
+		body          string
+		wantRateLimit bool
+		wantSecondary bool
+		wantMessage   string // substring, for errors other than rate limits
+	}{
+		{
+			name:          "quota exhausted",
+			status:        http.StatusForbidden,
+			headers:       map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)},
+			body:          `{"message": "API rate limit exceeded"}`,
+			wantRateLimit: true,
+		},
+		{
+			name:          "retry-after",
+			status:        http.StatusTooManyRequests,
+			headers:       map[string]string{"Retry-After": "60"},
+			wantRateLimit: true, wantSecondary: true,
+		},
+		{
+			name:          "secondary limit message",
+			status:        http.StatusForbidden,
+			body:          `{"message": "You have exceeded a secondary rate limit"}`,
+			wantRateLimit: true, wantSecondary: true,
+		},
+		{
+			name:        "permission denied",
+			status:      http.StatusForbidden,
+			body:        `{"message": "Resource not accessible by integration"}`,
+			wantMessage: "permission denied (HTTP 403: Resource not accessible by integration)",
+		},
+		{
+			name:        "not found",
+			status:      http.StatusNotFound,
+			body:        `{"message": "Not Found"}`,
+			wantMessage: "HTTP 404: Not Found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+			err := responseError(resp)
+
+			var rl *RateLimitError
+			if errors.As(err, &rl) != tt.wantRateLimit {
+				t.Fatalf("err = %v, rate limit %v", err, tt.wantRateLimit)
+			}
+			if rl != nil {
+				if rl.Secondary != tt.wantSecondary {
+					t.Errorf("Secondary = %v, want %v", rl.Secondary, tt.wantSecondary)
+				}
+				if !rl.Secondary && !rl.RetryAt.Equal(reset) {
+					t.Errorf("RetryAt = %s, want %s", rl.RetryAt, reset)
+				}
+				return
+			}
+			if !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("err = %q, want %q", err, tt.wantMessage)
+			}
+		})
+	}
+}
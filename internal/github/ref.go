@@ -0,0 +1,130 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FetchBranchInfo returns the head commit of a branch and the build check-run
+// on it, the first of buildCheckNames found (2+ API calls).
+func (c *Client) FetchBranchInfo(ctx context.Context, owner, repo, branch string, buildCheckNames []string) (*BranchInfo, error) {
+	ctx, stale := withStaleTracker(ctx)
+
+	commitURL := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, owner, repo, url.PathEscape(branch))
+	var resp branchCommitResponse
+	if err := c.getJSON(ctx, commitURL, &resp); err != nil {
+		return nil, fmt.Errorf("fetch branch %s: %w", branch, err)
+	}
+
+	info := &BranchInfo{
+		Branch: branch,
+		URL:    resp.HTMLURL,
+		HeadBuild: HeadBuild{
+			HeadSHA:       resp.SHA,
+			CommitAuthor:  resp.Commit.Author.Name,
+			CommitMessage: firstLine(resp.Commit.Message),
+		},
+	}
+	if t, err := time.Parse(time.RFC3339, resp.Commit.Author.Date); err == nil {
+		info.CommitDate = t
+	}
+
+	cr, name, err := c.FindCheckRun(ctx, owner, repo, resp.SHA, buildCheckNames)
+	if err != nil {
+		return nil, err
+	}
+	info.BuildCheckName = name
+	info.BuildStatus = cr.Status
+	info.BuildConclusion = cr.Conclusion
+	info.BuildCompletedAt = cr.CompletedAt
+
+	info.CachedAt = stale.oldest()
+	return info, nil
+}
+
+// maxReleasePages caps the release pages FetchReleaseInfo reads.
+const maxReleasePages = 10
+
+// FetchReleaseInfo looks up the release of a "vX.Y.Z" tag and the newest patch
+// release of X.Y (1 API call per 100 releases). Releases are listed newest
+// first, so every patch of X.Y comes before vX.Y.0 and the listing stops
+// there, or after maxReleasePages. Drafts and pre-releases are ignored. A tag
+// without a release is not an error: only LatestPatch is filled in then.
+func (c *Client) FetchReleaseInfo(ctx context.Context, owner, repo, tag string) (*ReleaseInfo, error) {
+	version, ok := parseVersion(tag)
+	if !ok {
+		return nil, fmt.Errorf("not a release version: %q", tag)
+	}
+
+	ctx, stale := withStaleTracker(ctx)
+	info := &ReleaseInfo{Tag: tag}
+	var (
+		latest     [3]int
+		haveLatest bool
+		sawFirst   bool // vX.Y.0 listed
+	)
+	pageURL := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=100", c.baseURL, owner, repo)
+	for page := 0; pageURL != "" && !sawFirst && page < maxReleasePages; page++ {
+		var releases []releaseEntry
+		next, err := c.getJSONPage(ctx, pageURL, &releases)
+		if err != nil {
+			return nil, fmt.Errorf("fetch releases: %w", err)
+		}
+		pageURL = next
+
+		for _, r := range releases {
+			if r.Draft || r.Prerelease {
+				continue
+			}
+			v, ok := parseVersion(r.TagName)
+			if !ok || v[0] != version[0] || v[1] != version[1] {
+				continue
+			}
+			if v[2] == 0 {
+				sawFirst = true
+			}
+			if r.TagName == tag {
+				info.URL = r.HTMLURL
+				if t, err := time.Parse(time.RFC3339, r.PublishedAt); err == nil {
+					info.PublishedAt = t
+				}
+			}
+			if !haveLatest || slices.Compare(v[:], latest[:]) > 0 {
+				latest, haveLatest = v, true
+				info.LatestPatch = r.TagName
+				info.LatestPatchURL = r.HTMLURL
+			}
+		}
+	}
+
+	// The tag may be newer than every release, e.g. before its release is
+	// published.
+	if !haveLatest || slices.Compare(version[:], latest[:]) >= 0 {
+		info.LatestPatch = tag
+		info.LatestPatchURL = info.URL
+	}
+
+	info.CachedAt = stale.oldest()
+	return info, nil
+}
+
+// parseVersion parses "vX.Y.Z" into its numbers.
+func parseVersion(s string) (v [3]int, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) != 3 || !strings.HasPrefix(s, "v") {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
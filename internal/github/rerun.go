@@ -0,0 +1,31 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// FetchWorkflowRunID returns the workflow run a GitHub Actions check-run
+// belongs to. The check-run ID doubles as the job ID.
+func (c *Client) FetchWorkflowRunID(ctx context.Context, owner, repo string, checkRunID int64) (int64, error) {
+	var job jobResponse
+	jobURL := fmt.Sprintf("%s/repos/%s/%s/actions/jobs/%d", c.baseURL, owner, repo, checkRunID)
+	if err := c.getJSON(ctx, jobURL, &job); err != nil {
+		return 0, fmt.Errorf("fetch job %d: %w (not a GitHub Actions check?)", checkRunID, err)
+	}
+	if job.RunID == 0 {
+		return 0, fmt.Errorf("job %d has no workflow run", checkRunID)
+	}
+	return job.RunID, nil
+}
+
+// RerunFailedJobs re-runs the failed and cancelled jobs of a workflow run and
+// the jobs depending on them. Requires a token with actions:write.
+func (c *Client) RerunFailedJobs(ctx context.Context, owner, repo string, runID int64) error {
+	rerunURL := fmt.Sprintf("%s/repos/%s/%s/actions/runs/%d/rerun-failed-jobs", c.baseURL, owner, repo, runID)
+	if err := c.post(ctx, rerunURL, http.StatusCreated); err != nil {
+		return fmt.Errorf("re-run workflow run %d: %w", runID, err)
+	}
+	return nil
+}
@@ -23,14 +23,65 @@ type commitResponse struct {
 	} `json:"commit"`
 }
 
+type compareResponse struct {
+	Status   string `json:"status"`
+	AheadBy  int    `json:"ahead_by"`
+	BehindBy int    `json:"behind_by"`
+	Commits  []struct {
+		SHA    string `json:"sha"`
+		Commit struct {
+			Author struct {
+				Name string `json:"name"`
+				Date string `json:"date"`
+			} `json:"author"`
+			Message string `json:"message"`
+		} `json:"commit"`
+		Author *struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	} `json:"commits"`
+}
+
+type branchCommitResponse struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	commitResponse
+}
+
+type releaseEntry struct {
+	TagName     string `json:"tag_name"`
+	HTMLURL     string `json:"html_url"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	PublishedAt string `json:"published_at"`
+}
+
 type checkRunEntry struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
 	Status      string `json:"status"`
 	Conclusion  string `json:"conclusion"`
+	StartedAt   string `json:"started_at"`
 	CompletedAt string `json:"completed_at"`
+	HTMLURL     string `json:"html_url"`
 }
 
 type checkRunsResponse struct {
-	CheckRuns []checkRunEntry `json:"check_runs"`
+	TotalCount int             `json:"total_count"`
+	CheckRuns  []checkRunEntry `json:"check_runs"`
+}
+
+type checkSuitesResponse struct {
+	TotalCount  int `json:"total_count"`
+	CheckSuites []struct {
+		ID         int64  `json:"id"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		App        struct {
+			Name string `json:"name"`
+		} `json:"app"`
+		LatestCheckRunsCount int `json:"latest_check_runs_count"`
+	} `json:"check_suites"`
 }
 
 // conditionalResult holds the result of a conditional HTTP GET request.
@@ -39,17 +90,15 @@ type conditionalResult struct {
 	ETag string
 	// NotModified is true when the server returned 304 Not Modified.
 	NotModified bool
+	// Link is the Link header value with the pagination URLs, if any.
+	Link string
 }
 
 // Domain types.
 
-// PRInfo contains PR metadata, last commit details, and CI build status.
-type PRInfo struct {
-	Number    int
-	Title     string
-	URL       string
-	HeadSHA   string
-	UpdatedAt time.Time
+// HeadBuild is the head commit of a PR or branch and its CI build status.
+type HeadBuild struct {
+	HeadSHA string
 
 	CommitAuthor  string
 	CommitDate    time.Time
@@ -59,6 +108,47 @@ type PRInfo struct {
 	BuildStatus      string // "completed", "in_progress", "queued", ""
 	BuildConclusion  string // "success", "failure", ""
 	BuildCompletedAt time.Time
+
+	// CachedAt is when the oldest cached response used for this data was
+	// fetched, set only when GitHub could not be reached; zero for live data.
+	CachedAt time.Time
+}
+
+// PRInfo contains PR metadata, last commit details, and CI build status.
+type PRInfo struct {
+	Number    int
+	Title     string
+	URL       string
+	UpdatedAt time.Time
+	HeadBuild
+
+	// ChecksState is the combined state of every check on the head commit:
+	// "success", "failure", "error", "pending" or "expected". Checks are its
+	// latest check-runs by name. Only the GraphQL query fetches them; both
+	// stay empty over REST.
+	ChecksState string
+	Checks      []CheckRun
+}
+
+// BranchInfo is the head commit of a branch and its CI build status.
+type BranchInfo struct {
+	Branch string
+	URL    string // commit page of the head
+	HeadBuild
+}
+
+// ReleaseInfo describes a release and the newest patch release of the same
+// minor version.
+type ReleaseInfo struct {
+	Tag         string // "v1.66.3"
+	URL         string
+	PublishedAt time.Time
+
+	LatestPatch    string // newest "v1.66.x" release; equals Tag when up to date
+	LatestPatchURL string
+
+	// CachedAt is set when GitHub could not be reached; see HeadBuild.
+	CachedAt time.Time
 }
 
 // PollCheckRunRequest contains parameters for polling a check-run.
@@ -70,8 +160,24 @@ type PollCheckRunRequest struct {
 	ETag      string // from previous poll; empty for first request
 }
 
+// PollHeadSHARequest contains parameters for polling a PR head commit.
+type PollHeadSHARequest struct {
+	Owner    string
+	Repo     string
+	PRNumber int
+	ETag     string // from previous poll; empty for first request
+}
+
+// HeadSHAResult represents the result of a PR head poll.
+type HeadSHAResult struct {
+	SHA         string // empty when NotModified
+	NotModified bool   // true when server returned 304
+	ETag        string // pass to next PollHeadSHARequest
+}
+
 // CheckRunResult represents the result of a single check-run poll.
 type CheckRunResult struct {
+	ID          int64     // check-run ID, 0 until the check appears
 	Status      string    // "queued", "in_progress", "completed"
 	Conclusion  string    // "success", "failure", "cancelled", "timed_out"
 	CompletedAt time.Time
@@ -79,6 +185,58 @@ type CheckRunResult struct {
 	ETag        string // pass to next PollCheckRunRequest
 }
 
+// Comparison lists the commits between a base and a head commit.
+type Comparison struct {
+	Status   string // "ahead", "behind", "identical", "diverged"
+	AheadBy  int    // commits in head that are not in base
+	BehindBy int    // commits in base that are not in head
+	Commits  []Commit
+}
+
+// Commit is a single commit in a Comparison.
+type Commit struct {
+	SHA     string
+	Author  string // GitHub login, or the git author name if not linked
+	Message string // first line only
+	Date    time.Time
+}
+
+// PollChecksRequest contains parameters for polling all checks of a commit.
+type PollChecksRequest struct {
+	Owner string
+	Repo  string
+	SHA   string
+	ETag  string // of the first check-runs page from the previous poll
+}
+
+// ChecksResult is every check-run and check-suite on a commit.
+type ChecksResult struct {
+	Runs        []CheckRun
+	Suites      []CheckSuite
+	NotModified bool   // true when the check-runs did not change; Runs and Suites are empty
+	ETag        string // pass to next PollChecksRequest
+}
+
+// CheckRun is a single check-run (e.g. one GitHub Actions job).
+type CheckRun struct {
+	ID          int64
+	Name        string
+	Status      string // "queued", "in_progress", "completed"
+	Conclusion  string // "success", "failure", "neutral", "skipped", "cancelled", "timed_out", ...
+	StartedAt   time.Time
+	CompletedAt time.Time
+	HTMLURL     string
+}
+
+// CheckSuite groups the check-runs of one app (e.g. one workflow run).
+type CheckSuite struct {
+	ID         int64
+	App        string
+	Status     string
+	Conclusion string
+	RunCount   int // check-runs created so far
+}
+
 type commitInfo struct {
 	Author  string
 	Date    time.Time
@@ -0,0 +1,160 @@
+// Package httpretry retries idempotent HTTP requests on transient failures:
+// connection errors, 429 and 5xx responses. Retries use exponential backoff
+// with full jitter and never outlive the request context's deadline.
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of the shared Client.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 250 * time.Millisecond
+	DefaultMaxDelay    = 2 * time.Second
+)
+
+// transport is the Transport of Client.
+var transport = &Transport{}
+
+// Client is the HTTP client shared by the github and registry packages.
+var Client = &http.Client{Transport: transport}
+
+// Transport is an http.RoundTripper that retries bodyless GET and HEAD requests.
+// Zero fields take the Default* values. It counts its requests per host; it
+// must not be copied after first use.
+type Transport struct {
+	Base        http.RoundTripper // default http.DefaultTransport
+	MaxAttempts int               // including the first one
+	BaseDelay   time.Duration     // backoff before the first retry, doubled on each one
+	MaxDelay    time.Duration     // backoff cap; a longer Retry-After is not waited for
+
+	mu    sync.Mutex
+	stats map[string]*HostStats
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead || req.Body != nil && req.Body != http.NoBody {
+		t.record(req.URL.Host, 1)
+		return base.RoundTrip(req)
+	}
+
+	maxAttempts := orDefault(t.MaxAttempts, DefaultMaxAttempts)
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if attempt == maxAttempts || !retryable(ctx, resp, err) {
+			t.record(req.URL.Host, attempt)
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if delay < 0 || !fitsDeadline(ctx, delay) {
+			t.record(req.URL.Host, attempt)
+			return resp, err
+		}
+		if resp != nil {
+			// Drain so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			t.record(req.URL.Host, attempt)
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns the wait before the retry after attempt: Retry-After when
+// the server sent one, otherwise a random delay up to BaseDelay·2^(attempt-1).
+// It is negative when Retry-After asks for longer than MaxDelay.
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	maxDelay := orDefault(t.MaxDelay, DefaultMaxDelay)
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			if d := time.Duration(secs) * time.Second; d <= maxDelay {
+				return d
+			}
+			return -1
+		}
+	}
+	ceiling := min(orDefault(t.BaseDelay, DefaultBaseDelay)<<(attempt-1), maxDelay)
+	return rand.N(ceiling) + 1
+}
+
+// fitsDeadline reports whether waiting d still leaves time for another attempt.
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+func orDefault[T int | time.Duration](v, def T) T {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// HostStats counts the requests sent to one host.
+type HostStats struct {
+	Host     string
+	Requests int
+	Attempts int // Requests plus retries
+}
+
+func (t *Transport) record(host string, attempts int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stats == nil {
+		t.stats = make(map[string]*HostStats)
+	}
+	s, ok := t.stats[host]
+	if !ok {
+		s = &HostStats{Host: host}
+		t.stats[host] = s
+	}
+	s.Requests++
+	s.Attempts += attempts
+}
+
+// Stats returns the per-host request and attempt counts of t so far.
+func (t *Transport) Stats() []HostStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]HostStats, 0, len(t.stats))
+	for _, s := range t.stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+// Stats returns the per-host request and attempt counts of Client so far.
+func Stats() []HostStats {
+	return transport.Stats()
+}
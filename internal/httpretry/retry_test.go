@@ -0,0 +1,132 @@
+package httpretry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		statuses   []int  // per attempt; the last one repeats
+		drop       int    // attempts whose connection is closed without a response
+		retryAfter string // sent with error statuses
+		maxDelay   time.Duration
+		timeout    time.Duration
+
+		wantStatus   int // 0 for an error
+		wantAttempts int
+	}{
+		{name: "success", statuses: []int{200}, wantStatus: 200, wantAttempts: 1},
+		{name: "5xx then success", statuses: []int{503, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "429 then success", statuses: []int{429, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "connection error then success", drop: 1, statuses: []int{200}, wantStatus: 200, wantAttempts: 2},
+		{name: "HEAD is retried", method: http.MethodHead, statuses: []int{502, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "attempts exhausted", statuses: []int{500}, wantStatus: 500, wantAttempts: 3},
+		{name: "connection errors exhausted", drop: 3, wantAttempts: 3},
+		{name: "4xx is final", statuses: []int{404, 200}, wantStatus: 404, wantAttempts: 1},
+		{name: "POST is not retried", method: http.MethodPost, statuses: []int{503, 200}, wantStatus: 503, wantAttempts: 1},
+		{name: "request with a body is not retried", body: "{}", statuses: []int{503, 200}, wantStatus: 503, wantAttempts: 1},
+		{name: "Retry-After within MaxDelay", statuses: []int{429, 200}, retryAfter: "0", wantStatus: 200, wantAttempts: 2},
+		{name: "Retry-After over MaxDelay", statuses: []int{429, 200}, retryAfter: "1", maxDelay: 100 * time.Millisecond, wantStatus: 429, wantAttempts: 1},
+		{name: "retry would pass the deadline", statuses: []int{503, 200}, retryAfter: "1", timeout: 200 * time.Millisecond, wantStatus: 503, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			hits := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				hits++
+				n := hits
+				mu.Unlock()
+
+				if n <= tt.drop {
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					_ = conn.Close()
+					return
+				}
+				status := tt.statuses[min(n-tt.drop, len(tt.statuses))-1]
+				if status != http.StatusOK && tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+			}))
+			defer srv.Close()
+
+			tr := &Transport{BaseDelay: time.Millisecond, MaxDelay: tt.maxDelay}
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequestWithContext(ctx, method, srv.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := (&http.Client{Transport: tr}).Do(req)
+			status := 0
+			if err == nil {
+				status = resp.StatusCode
+				_ = resp.Body.Close()
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d (err %v), want %d", status, err, tt.wantStatus)
+			}
+			mu.Lock()
+			if hits != tt.wantAttempts {
+				t.Errorf("server saw %d attempts, want %d", hits, tt.wantAttempts)
+			}
+			mu.Unlock()
+			u, _ := url.Parse(srv.URL)
+			want := []HostStats{{Host: u.Host, Requests: 1, Attempts: tt.wantAttempts}}
+			if got := tr.Stats(); len(got) != 1 || got[0] != want[0] {
+				t.Errorf("Stats = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTransportStatsPerTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	a, b := &Transport{}, &Transport{}
+	for _, tr := range []*Transport{a, a, b} {
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+	if got := a.Stats(); len(got) != 1 || got[0].Requests != 2 {
+		t.Errorf("a.Stats = %+v, want 2 requests", got)
+	}
+	if got := b.Stats(); len(got) != 1 || got[0].Requests != 1 {
+		t.Errorf("b.Stats = %+v, want 1 request", got)
+	}
+}
@@ -0,0 +1,309 @@
+// Package imagetag classifies Deckhouse image tags by what they were built
+// from: a pull request, a branch or a release.
+//
+// Tags are matched against a table of rules. Each rule maps a tag regexp, and
+// optionally an image repository regexp, to the source of the image, its
+// edition and the names of the CI check-runs that build it.
+package imagetag
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultEdition is the edition of tags without an edition suffix.
+const DefaultEdition = "FE"
+
+// Kind is what an image tag was built from.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	PR           // "pr15160", "pr15160-ce"
+	Branch       // "main", "release-1.67", "main-ce"
+	Release      // "v1.66.3"
+)
+
+var kindNames = map[Kind]string{
+	Unknown: "unknown",
+	PR:      "pr",
+	Branch:  "branch",
+	Release: "release",
+}
+
+// String returns the stable machine-readable name, e.g. "branch".
+func (k Kind) String() string { return kindNames[k] }
+
+// Tag is a classified image tag.
+type Tag struct {
+	Name     string // the tag as is
+	Kind     Kind
+	Edition  string // "FE", "CE", ...; empty for Unknown
+	PRNumber int    // Kind PR
+	Branch   string // Kind Branch: "main", "release-1.67"
+	Version  string // Kind Release: "v1.66.3"
+	// Checks are the candidate names of the build check-run, first found
+	// wins: ["Build CE"]. Empty for Unknown.
+	Checks []string
+	Rule   string // name of the matching rule
+}
+
+// Ref returns a short human-readable name of the source: "PR #15160",
+// "main", "v1.66.3", or the tag itself when unknown.
+func (t Tag) Ref() string {
+	switch t.Kind {
+	case PR:
+		return fmt.Sprintf("PR #%d", t.PRNumber)
+	case Branch:
+		return t.Branch
+	case Release:
+		return t.Version
+	default:
+		return t.Name
+	}
+}
+
+// Rule maps image tags to their source. It is the element of the "tag-rules"
+// list in config files.
+//
+// Match must have exactly one of the named groups "pr", "branch" or
+// "version", which sets the kind of the tag. An "edition" group, in Match or
+// Image, overrides Edition. Checks may refer to {pr}, {branch}, {version} and
+// {edition}.
+type Rule struct {
+	Name    string   `json:"name,omitempty"`
+	Match   string   `json:"match"`           // regexp on the tag
+	Image   string   `json:"image,omitempty"` // regexp on the image repository, e.g. "sys/deckhouse-oss"; empty matches any
+	Edition string   `json:"edition,omitempty"`
+	Checks  []string `json:"checks"` // check-run name templates: "Build {edition}"
+}
+
+// editions are the editions the default rules recognise in tag suffixes.
+const editions = "fe|ce|ee|se|be"
+
+// DefaultRules returns the built-in rules for FE, CE, EE, SE and BE images.
+// Other PR tags still resolve to their PR: "pr15160-ee-debug" with the
+// edition of its first suffix, "pr15160-debug" with the default edition.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:    "pr",
+			Match:   `^pr(?P<pr>\d+)(?:-(?P<edition>` + editions + `))?$`,
+			Edition: DefaultEdition,
+			Checks:  []string{"Build {edition}"},
+		},
+		{
+			Name:    "branch",
+			Match:   `^(?P<branch>main|release-\d+\.\d+)(?:-(?P<edition>` + editions + `))?$`,
+			Edition: DefaultEdition,
+			Checks:  []string{"Build {edition}"},
+		},
+		{
+			Name:    "release",
+			Match:   `^(?P<version>v\d+\.\d+\.\d+)$`,
+			Edition: DefaultEdition,
+			Checks:  []string{"Build {edition}"},
+		},
+		{
+			Name:    "pr-other",
+			Match:   `^pr(?P<pr>\d+)(?:-(?P<edition>` + editions + `)(?:-.*)?|-.*)?$`,
+			Edition: DefaultEdition,
+			Checks:  []string{"Build {edition}"},
+		},
+	}
+}
+
+// Rules is a validated rule table; the first matching rule wins.
+type Rules struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	kind  Kind
+	match *regexp.Regexp
+	image *regexp.Regexp // nil matches any repository
+}
+
+// kindGroups maps the named group that identifies a kind to the kind.
+var kindGroups = map[string]Kind{"pr": PR, "branch": Branch, "version": Release}
+
+var placeholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// Compile validates rules and prepares them for matching.
+func Compile(rules []Rule) (*Rules, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no tag rules")
+	}
+
+	out := &Rules{rules: make([]compiledRule, 0, len(rules))}
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+			r.Name = name
+		}
+		cr, err := compileRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("tag rule %s: %w", name, err)
+		}
+		out.rules = append(out.rules, cr)
+	}
+	return out, nil
+}
+
+func compileRule(r Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: r}
+
+	var err error
+	if r.Match == "" {
+		return cr, fmt.Errorf("match is empty")
+	}
+	if cr.match, err = regexp.Compile(r.Match); err != nil {
+		return cr, fmt.Errorf("match: %w", err)
+	}
+	if r.Image != "" {
+		if cr.image, err = regexp.Compile(r.Image); err != nil {
+			return cr, fmt.Errorf("image: %w", err)
+		}
+	}
+
+	hasEdition := r.Edition != ""
+	for _, g := range cr.match.SubexpNames() {
+		switch k, ok := kindGroups[g]; {
+		case ok && cr.kind != Unknown:
+			return cr, fmt.Errorf("match has more than one of the groups pr, branch and version")
+		case ok:
+			cr.kind = k
+		case g == "edition":
+			hasEdition = true
+		case g != "":
+			return cr, fmt.Errorf("match has unknown group %q (want pr, branch, version or edition)", g)
+		}
+	}
+	if cr.kind == Unknown {
+		return cr, fmt.Errorf("match has none of the groups pr, branch and version")
+	}
+	if cr.image != nil {
+		for _, g := range cr.image.SubexpNames() {
+			switch g {
+			case "":
+			case "edition":
+				hasEdition = true
+			default:
+				return cr, fmt.Errorf("image has unknown group %q (want edition)", g)
+			}
+		}
+	}
+	if !hasEdition {
+		return cr, fmt.Errorf("edition is empty and there is no edition group")
+	}
+
+	if len(r.Checks) == 0 {
+		return cr, fmt.Errorf("no checks")
+	}
+	for _, tmpl := range r.Checks {
+		for _, m := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+			switch m[1] {
+			case "edition":
+			case "pr", "branch", "version":
+				if kindGroups[m[1]] != cr.kind {
+					return cr, fmt.Errorf("check %q: {%s} is not set for %s tags", tmpl, m[1], cr.kind)
+				}
+			default:
+				return cr, fmt.Errorf("check %q: unknown placeholder {%s}", tmpl, m[1])
+			}
+		}
+	}
+	return cr, nil
+}
+
+// Default is the table of DefaultRules.
+var Default = mustCompile(DefaultRules())
+
+func mustCompile(rules []Rule) *Rules {
+	rs, err := Compile(rules)
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+// Names returns the rule names in matching order.
+func (rs *Rules) Names() []string {
+	names := make([]string, len(rs.rules))
+	for i, r := range rs.rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// Parse classifies the tag of an image from repository, e.g.
+// ("sys/deckhouse-oss", "pr15160-ce"). Tags no rule matches are Unknown.
+func (rs *Rules) Parse(repository, tag string) Tag {
+	for _, r := range rs.rules {
+		if t, ok := r.parse(repository, tag); ok {
+			return t
+		}
+	}
+	return Tag{Name: tag}
+}
+
+func (r *compiledRule) parse(repository, tag string) (Tag, bool) {
+	m := r.match.FindStringSubmatch(tag)
+	if m == nil {
+		return Tag{}, false
+	}
+	groups := make(map[string]string)
+	if r.image != nil {
+		im := r.image.FindStringSubmatch(repository)
+		if im == nil {
+			return Tag{}, false
+		}
+		collectGroups(groups, r.image, im)
+	}
+	// Groups of the tag win over those of the repository.
+	collectGroups(groups, r.match, m)
+
+	t := Tag{Name: tag, Kind: r.kind, Edition: r.Edition, Rule: r.Name}
+	if e := groups["edition"]; e != "" {
+		t.Edition = strings.ToUpper(e)
+	}
+	switch r.kind {
+	case PR:
+		n, err := strconv.Atoi(groups["pr"])
+		if err != nil || n <= 0 {
+			return Tag{}, false
+		}
+		t.PRNumber = n
+	case Branch:
+		t.Branch = groups["branch"]
+	case Release:
+		t.Version = groups["version"]
+	}
+	if t.Edition == "" {
+		return Tag{}, false
+	}
+
+	vars := strings.NewReplacer(
+		"{edition}", t.Edition,
+		"{pr}", groups["pr"],
+		"{branch}", t.Branch,
+		"{version}", t.Version,
+	)
+	for _, tmpl := range r.Checks {
+		t.Checks = append(t.Checks, vars.Replace(tmpl))
+	}
+	return t, true
+}
+
+// collectGroups adds the non-empty named groups of a match to groups.
+func collectGroups(groups map[string]string, re *regexp.Regexp, m []string) {
+	for i, name := range re.SubexpNames() {
+		if name != "" && m[i] != "" {
+			groups[name] = m[i]
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package imagetag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRules(t *testing.T) {
+	build := func(edition string) []string { return []string{"Build " + edition} }
+	tests := []struct {
+		tag  string
+		want Tag
+	}{
+		{"pr15160", Tag{Kind: PR, Edition: "FE", PRNumber: 15160, Checks: build("FE"), Rule: "pr"}},
+		{"pr15160-ce", Tag{Kind: PR, Edition: "CE", PRNumber: 15160, Checks: build("CE"), Rule: "pr"}},
+		{"pr15160-be", Tag{Kind: PR, Edition: "BE", PRNumber: 15160, Checks: build("BE"), Rule: "pr"}},
+		{"main", Tag{Kind: Branch, Edition: "FE", Branch: "main", Checks: build("FE"), Rule: "branch"}},
+		{"main-ee", Tag{Kind: Branch, Edition: "EE", Branch: "main", Checks: build("EE"), Rule: "branch"}},
+		{"release-1.67-se", Tag{Kind: Branch, Edition: "SE", Branch: "release-1.67", Checks: build("SE"), Rule: "branch"}},
+		{"v1.66.3", Tag{Kind: Release, Edition: "FE", Version: "v1.66.3", Checks: build("FE"), Rule: "release"}},
+		{"pr15160-ee-debug", Tag{Kind: PR, Edition: "EE", PRNumber: 15160, Checks: build("EE"), Rule: "pr-other"}},
+		{"pr15160-debug", Tag{Kind: PR, Edition: "FE", PRNumber: 15160, Checks: build("FE"), Rule: "pr-other"}},
+		{"pr15160-eek", Tag{Kind: PR, Edition: "FE", PRNumber: 15160, Checks: build("FE"), Rule: "pr-other"}},
+		{"pr0", Tag{}},
+		{"v1.66", Tag{}},
+		{"latest", Tag{}},
+		{"mainline", Tag{}},
+	}
+
+	for _, tt := range tests {
+		tt.want.Name = tt.tag
+		if got := Default.Parse("sys/deckhouse-oss", tt.tag); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	rules, err := Compile([]Rule{
+		{Name: "debug", Match: `^pr(?P<pr>\d+)-(?P<edition>ee)-debug$`, Checks: []string{"Build {edition} debug", "Build {edition}"}},
+		{Name: "edition-image", Match: `^(?P<branch>main)$`, Image: `^deckhouse/(?P<edition>ce|ee)$`, Checks: []string{"Build {edition} ({branch})"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := rules.Parse("sys/deckhouse-oss", "pr42-ee-debug")
+	if got.Kind != PR || got.PRNumber != 42 || got.Edition != "EE" || !reflect.DeepEqual(got.Checks, []string{"Build EE debug", "Build EE"}) {
+		t.Errorf("debug tag = %+v", got)
+	}
+
+	got = rules.Parse("deckhouse/ce", "main")
+	if got.Kind != Branch || got.Edition != "CE" || !reflect.DeepEqual(got.Checks, []string{"Build CE (main)"}) {
+		t.Errorf("edition from image = %+v", got)
+	}
+	if got := rules.Parse("sys/deckhouse-oss", "main"); got.Kind != Unknown {
+		t.Errorf("image mismatch = %+v, want Unknown", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{"bad regexp", Rule{Match: `^pr(?P<pr>\d+`, Edition: "FE", Checks: []string{"Build"}}, "match:"},
+		{"no kind group", Rule{Match: `^pr\d+$`, Edition: "FE", Checks: []string{"Build"}}, "none of the groups"},
+		{"two kind groups", Rule{Match: `^(?P<pr>\d+)-(?P<branch>\w+)$`, Edition: "FE", Checks: []string{"Build"}}, "more than one"},
+		{"unknown group", Rule{Match: `^pr(?P<pr>\d+)(?P<arch>-arm)?$`, Edition: "FE", Checks: []string{"Build"}}, `unknown group "arch"`},
+		{"no edition", Rule{Match: `^pr(?P<pr>\d+)$`, Checks: []string{"Build"}}, "edition"},
+		{"no checks", Rule{Match: `^pr(?P<pr>\d+)$`, Edition: "FE"}, "no checks"},
+		{"unknown placeholder", Rule{Match: `^pr(?P<pr>\d+)$`, Edition: "FE", Checks: []string{"Build {arch}"}}, "unknown placeholder {arch}"},
+		{"placeholder of another kind", Rule{Match: `^pr(?P<pr>\d+)$`, Edition: "FE", Checks: []string{"Build {branch}"}}, "{branch} is not set for pr tags"},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile([]Rule{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRef(t *testing.T) {
+	for tag, want := range map[string]string{
+		"pr42-se":      "PR #42",
+		"release-1.67": "release-1.67",
+		"v1.66.3":      "v1.66.3",
+		"latest":       "latest",
+	} {
+		if got := Default.Parse("", tag).Ref(); got != want {
+			t.Errorf("%s: Ref() = %q, want %q", tag, got, want)
+		}
+	}
+}
@@ -3,28 +3,30 @@ package kube
 import (
 	"context"
 	"fmt"
-	"path/filepath"
+	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
 )
 
 type Client struct {
-	cs kubernetes.Interface
+	cs   kubernetes.Interface
+	opts Options
 }
 
-func NewClient() (*Client, error) {
-	config, err := rest.InClusterConfig()
+// NewClient builds a client using the standard kubeconfig loading rules
+// ($KUBECONFIG merging, ~/.kube/config, in-cluster config as a fallback).
+func NewClient(opts Options) (*Client, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = opts.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.Context}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
 	if err != nil {
-		kubeconfig := filepath.Join(homedir.HomeDir(), ".kube", "config")
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, fmt.Errorf("cannot create k8s config: %w", err)
-		}
+		return nil, fmt.Errorf("cannot create k8s config: %w", err)
 	}
 
 	cs, err := kubernetes.NewForConfig(config)
@@ -32,50 +34,211 @@ func NewClient() (*Client, error) {
 		return nil, fmt.Errorf("cannot create k8s client: %w", err)
 	}
 
-	return &Client{cs: cs}, nil
+	return NewClientFromClientset(cs, opts), nil
+}
+
+// NewClientFromClientset wraps an existing clientset, such as the fake one
+// from k8s.io/client-go/kubernetes/fake. Kubeconfig and Context are ignored.
+func NewClientFromClientset(cs kubernetes.Interface, opts Options) *Client {
+	return &Client{cs: cs, opts: opts.withDefaults()}
+}
+
+// Contexts returns the sorted context names of the kubeconfig selected by the
+// standard loading rules, or of the file at kubeconfig if non-empty.
+func Contexts(kubeconfig string) ([]string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+
+	raw, err := rules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubeconfig: %w", err)
+	}
+
+	names := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
 }
 
 func (c *Client) FetchClusterInfo(ctx context.Context) (*ClusterInfo, error) {
-	pods, err := c.cs.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: "app=deckhouse",
+	pods, err := c.cs.CoreV1().Pods(c.opts.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: c.opts.Selector,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("cannot list pods: %w", err)
 	}
 	if len(pods.Items) == 0 {
-		return nil, fmt.Errorf("no deckhouse pods found in %s", namespace)
+		return nil, fmt.Errorf("no pods matching %q found in %s", c.opts.Selector, c.opts.Namespace)
 	}
 
+	info := &ClusterInfo{Pods: make([]PodInfo, 0, len(pods.Items))}
+	for i := range pods.Items {
+		info.Pods = append(info.Pods, c.podInfo(&pods.Items[i]))
+	}
+	sort.Slice(info.Pods, func(i, j int) bool { return info.Pods[i].Name < info.Pods[j].Name })
+
 	// Pick the first running pod, or just the first one
-	pod := pods.Items[0]
-	for _, p := range pods.Items {
-		if p.Status.Phase == "Running" {
-			pod = p
+	pod := &info.Pods[0]
+	for i := range info.Pods {
+		if info.Pods[i].Phase == string(corev1.PodRunning) {
+			pod = &info.Pods[i]
 			break
 		}
 	}
 
-	info := &ClusterInfo{
-		PodName:    pod.Name,
-		PodCreated: pod.CreationTimestamp.Time,
-		PodPhase:   string(pod.Status.Phase),
+	info.PodName = pod.Name
+	info.PodCreated = pod.Created
+	info.PodPhase = pod.Phase
+	if main := pod.Main(); main != nil {
+		info.Image = main.Image
+		info.Registry, info.Repository, info.Tag = parseImage(info.Image)
+		info.RunningDigest = main.Digest
 	}
 
-	if len(pod.Spec.Containers) > 0 {
-		info.Image = pod.Spec.Containers[0].Image
-		info.Registry, info.Repository, info.Tag = parseImage(info.Image)
+	// Events are best effort, like the Deployment annotations below.
+	info.Events, _ = c.fetchWarningEvents(ctx, pod.Name)
+	info.Platform = c.nodePlatform(ctx, pod.Node)
+
+	info.RegistryCreds, _ = c.fetchRegistryCreds(ctx)
+
+	// The annotations are optional; not being allowed to read the Deployment is fine too.
+	if deploy, err := c.cs.AppsV1().Deployments(c.opts.Namespace).Get(ctx, c.opts.Deployment, metav1.GetOptions{}); err == nil {
+		info.GitHubRepo = deploy.Annotations[RepoAnnotation]
+		info.Commit = deploy.Annotations[CommitAnnotation]
+	}
+
+	return info, nil
+}
+
+// nodePlatform returns "os/arch" from the well-known labels of node, empty if
+// the node cannot be read: reading nodes is not allowed everywhere.
+func (c *Client) nodePlatform(ctx context.Context, node string) string {
+	if node == "" {
+		return ""
+	}
+	n, err := c.cs.CoreV1().Nodes().Get(ctx, node, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	goos, arch := n.Labels[corev1.LabelOSStable], n.Labels[corev1.LabelArchStable]
+	if goos == "" || arch == "" {
+		return ""
 	}
+	return goos + "/" + arch
+}
 
-	if len(pod.Status.ContainerStatuses) > 0 {
-		imageID := pod.Status.ContainerStatuses[0].ImageID
-		if idx := strings.Index(imageID, "@"); idx != -1 {
-			info.RunningDigest = imageID[idx+1:]
+// podInfo collects the replica details of pod. The main container is the one
+// named after the Deployment, or the first one.
+func (c *Client) podInfo(pod *corev1.Pod) PodInfo {
+	info := PodInfo{
+		Name:    pod.Name,
+		Node:    pod.Spec.NodeName,
+		Phase:   string(pod.Status.Phase),
+		Created: pod.CreationTimestamp.Time,
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			info.Ready = cond.Status == corev1.ConditionTrue
 		}
 	}
 
-	info.RegistryCreds, _ = c.fetchRegistryCreds(ctx)
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, st := range pod.Status.InitContainerStatuses {
+		statuses["init/"+st.Name] = st
+	}
+	for _, st := range pod.Status.ContainerStatuses {
+		statuses[st.Name] = st
+	}
+	add := func(ctr corev1.Container, init bool) {
+		key := ctr.Name
+		if init {
+			key = "init/" + key
+		}
+		st := statuses[key]
+		ci := ContainerInfo{
+			Name:     ctr.Name,
+			Init:     init,
+			Image:    ctr.Image,
+			Digest:   imageDigest(st.ImageID),
+			Ready:    st.Ready,
+			Restarts: st.RestartCount,
+		}
+		if w := st.State.Waiting; w != nil {
+			ci.Waiting, ci.WaitingMessage = w.Reason, w.Message
+		}
+		if t := st.LastTerminationState.Terminated; t != nil {
+			ci.LastTermination = &Termination{Reason: t.Reason, ExitCode: t.ExitCode, FinishedAt: t.FinishedAt.Time}
+		}
+		info.Containers = append(info.Containers, ci)
+	}
+	for _, ctr := range pod.Spec.InitContainers {
+		add(ctr, true)
+	}
+	main := -1
+	for _, ctr := range pod.Spec.Containers {
+		if ctr.Name == c.opts.Deployment && main < 0 {
+			main = len(info.Containers)
+		}
+		add(ctr, false)
+	}
+	if main < 0 && len(pod.Spec.Containers) > 0 {
+		main = len(pod.Spec.InitContainers)
+	}
+	if main >= 0 {
+		info.Containers[main].Main = true
+	}
+	return info
+}
 
-	return info, nil
+// maxEvents limits ClusterInfo.Events.
+const maxEvents = 5
+
+// fetchWarningEvents returns the newest Warning events of the pod.
+func (c *Client) fetchWarningEvents(ctx context.Context, podName string) ([]Event, error) {
+	list, err := c.cs.CoreV1().Events(c.opts.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + podName + ",type=" + corev1.EventTypeWarning,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list events: %w", err)
+	}
+
+	var events []Event
+	for _, e := range list.Items {
+		// Not every clientset applies field selectors, so check again.
+		if e.InvolvedObject.Name != podName || e.Type != corev1.EventTypeWarning {
+			continue
+		}
+		ev := Event{Reason: e.Reason, Message: strings.TrimSpace(e.Message), Count: e.Count, LastSeen: e.LastTimestamp.Time}
+		if e.Series != nil {
+			ev.Count, ev.LastSeen = e.Series.Count, e.Series.LastObservedTime.Time
+		}
+		if ev.LastSeen.IsZero() {
+			ev.LastSeen = e.EventTime.Time
+		}
+		if ev.LastSeen.IsZero() {
+			ev.LastSeen = e.CreationTimestamp.Time
+		}
+		if ev.Count < 1 {
+			ev.Count = 1
+		}
+		events = append(events, ev)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].LastSeen.After(events[j].LastSeen) })
+	if len(events) > maxEvents {
+		events = events[:maxEvents]
+	}
+	return events, nil
+}
+
+// imageDigest extracts "sha256:..." from a container status ImageID
+// such as "registry/repo@sha256:...".
+func imageDigest(imageID string) string {
+	if idx := strings.Index(imageID, "@"); idx != -1 {
+		return imageID[idx+1:]
+	}
+	return ""
 }
 
 func parseImage(image string) (registry, repo, tag string) {
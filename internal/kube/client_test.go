@@ -0,0 +1,107 @@
+package kube
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFetchClusterInfoLocation(t *testing.T) {
+	opts := Options{Namespace: "d8-custom", Deployment: "dh", Selector: "app=dh"}
+	cs := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "dh-1", Namespace: opts.Namespace, Labels: map[string]string{"app": "dh"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "deckhouse", Image: "dev-registry.deckhouse.io/sys/deckhouse-oss:pr15160"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name: opts.Deployment, Namespace: opts.Namespace,
+			Annotations: map[string]string{RepoAnnotation: "fork/deckhouse"},
+		}},
+	)
+
+	info, err := NewClientFromClientset(cs, opts).FetchClusterInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PodName != "dh-1" || info.Tag != "pr15160" || info.GitHubRepo != "fork/deckhouse" {
+		t.Errorf("FetchClusterInfo = pod %q, tag %q, repo %q; want dh-1, pr15160, fork/deckhouse", info.PodName, info.Tag, info.GitHubRepo)
+	}
+
+	if _, err := NewClientFromClientset(cs, Options{}).FetchClusterInfo(context.Background()); err == nil {
+		t.Error("FetchClusterInfo with the default location found a pod in d8-custom")
+	}
+}
+
+func TestFetchClusterInfoPlatform(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "worker-arm",
+		Labels: map[string]string{corev1.LabelOSStable: "linux", corev1.LabelArchStable: "arm64"},
+	}}
+	tests := []struct {
+		name     string
+		nodeName string
+		objects  []runtime.Object
+		want     string
+	}{
+		{name: "node labels", nodeName: "worker-arm", objects: []runtime.Object{node}, want: "linux/arm64"},
+		{name: "node not readable", nodeName: "worker-arm"},
+		{name: "pod not scheduled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "deckhouse-1", Namespace: DefaultNamespace, Labels: map[string]string{"app": "deckhouse"}},
+				Spec:       corev1.PodSpec{NodeName: tt.nodeName},
+			}
+			objects := append([]runtime.Object{pod}, tt.objects...)
+			c := NewClientFromClientset(fake.NewSimpleClientset(objects...), Options{})
+
+			info, err := c.FetchClusterInfo(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Platform != tt.want {
+				t.Errorf("Platform = %q, want %q", info.Platform, tt.want)
+			}
+		})
+	}
+}
+
+func TestContexts(t *testing.T) {
+	const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+  - name: c
+    cluster: {server: https://127.0.0.1:6443}
+users:
+  - name: u
+contexts:
+  - name: stage
+    context: {cluster: c, user: u}
+  - name: dev
+    context: {cluster: c, user: u}
+current-context: stage
+`
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Contexts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dev", "stage"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Contexts = %v, want %v", got, want)
+	}
+}
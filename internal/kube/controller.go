@@ -0,0 +1,216 @@
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DefaultControllerPort is the port of the deckhouse-controller HTTP server
+// serving /metrics and /readyz.
+const DefaultControllerPort = "4222"
+
+// Converge states of ControllerStatus.
+const (
+	ConvergeStartup    = "startup"     // the first converge after the start is running
+	ConvergeInProgress = "in_progress" // tasks are queued in the main queue
+	ConvergeDone       = "done"
+)
+
+// ControllerStatus is what deckhouse-controller reports about its work.
+type ControllerStatus struct {
+	Pod       string
+	MainQueue int    // tasks in the main queue
+	Converge  string // ConvergeStartup, ConvergeInProgress or ConvergeDone
+	// ModuleErrors are the modules whose hooks failed since the controller
+	// started, most errors first.
+	ModuleErrors []ModuleErrors
+}
+
+// ModuleErrors counts the hook errors of one module.
+type ModuleErrors struct {
+	Module string
+	Errors int
+}
+
+// String returns "module (errors)", e.g. "cni-cilium (3)".
+func (m ModuleErrors) String() string {
+	return fmt.Sprintf("%s (%d)", m.Module, m.Errors)
+}
+
+// FetchControllerStatus reads the readiness and Prometheus metrics of the
+// deckhouse-controller in pod through the API server proxy. The controller
+// is not ready until its first converge is done.
+func (c *Client) FetchControllerStatus(ctx context.Context, pod string) (*ControllerStatus, error) {
+	st := &ControllerStatus{Pod: pod, Converge: ConvergeDone}
+
+	// /readyz answers with a server error status until the startup converge
+	// ends; any other failure (denied pods/proxy, wrong port, ...) means the
+	// readiness is unknown.
+	if _, err := c.proxyGet(ctx, pod, "/readyz"); err != nil {
+		if !notReady(err) {
+			return nil, fmt.Errorf("cannot read controller readiness: %w", err)
+		}
+		st.Converge = ConvergeStartup
+	}
+
+	body, err := c.proxyGet(ctx, pod, "/metrics")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read controller metrics: %w", err)
+	}
+	samples, err := parseMetrics(body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse controller metrics: %w", err)
+	}
+
+	mainQueue := false
+	errs := make(map[string]int)
+	for _, s := range samples {
+		switch {
+		case strings.HasSuffix(s.name, "tasks_queue_length") && s.labels["queue"] == "main":
+			st.MainQueue, mainQueue = int(s.value), true
+		// Errors that are allowed to happen do not make a module fail.
+		case strings.HasSuffix(s.name, "_errors_total") && !strings.Contains(s.name, "allowed") && s.labels["module"] != "":
+			errs[s.labels["module"]] += int(s.value)
+		}
+	}
+	if !mainQueue {
+		return nil, fmt.Errorf("controller metrics have no main queue length")
+	}
+	if st.MainQueue > 0 && st.Converge == ConvergeDone {
+		st.Converge = ConvergeInProgress
+	}
+
+	for module, n := range errs {
+		if n > 0 {
+			st.ModuleErrors = append(st.ModuleErrors, ModuleErrors{Module: module, Errors: n})
+		}
+	}
+	sort.Slice(st.ModuleErrors, func(i, j int) bool {
+		a, b := st.ModuleErrors[i], st.ModuleErrors[j]
+		if a.Errors != b.Errors {
+			return a.Errors > b.Errors
+		}
+		return a.Module < b.Module
+	})
+	return st, nil
+}
+
+// notReady reports whether err is a 5xx answer of the controller itself.
+func notReady(err error) bool {
+	var status apierrors.APIStatus
+	return errors.As(err, &status) && status.Status().Code >= 500
+}
+
+func (c *Client) proxyGet(ctx context.Context, pod, path string) ([]byte, error) {
+	return c.cs.CoreV1().Pods(c.opts.Namespace).ProxyGet("http", pod, c.opts.ControllerPort, path, nil).DoRaw(ctx)
+}
+
+// metricSample is one line of the Prometheus text format.
+type metricSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseMetrics parses the Prometheus text exposition format, skipping
+// comments; timestamps are ignored.
+func parseMetrics(data []byte) ([]metricSample, error) {
+	var samples []metricSample
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		samples = append(samples, s)
+	}
+	return samples, sc.Err()
+}
+
+func parseSample(line string) (metricSample, error) {
+	s := metricSample{labels: make(map[string]string)}
+
+	end := strings.IndexAny(line, "{ ")
+	if end <= 0 {
+		return s, fmt.Errorf("no value")
+	}
+	s.name, line = line[:end], line[end:]
+
+	if line[0] == '{' {
+		rest, err := parseLabels(line[1:], s.labels)
+		if err != nil {
+			return s, err
+		}
+		line = rest
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return s, fmt.Errorf("no value")
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, fmt.Errorf("value: %w", err)
+	}
+	s.value = v
+	return s, nil
+}
+
+// parseLabels reads `name="value",...}` into labels and returns the rest of
+// the line after the closing brace.
+func parseLabels(line string, labels map[string]string) (string, error) {
+	for {
+		line = strings.TrimLeft(line, " ,")
+		if line == "" {
+			return "", fmt.Errorf("unterminated labels")
+		}
+		if line[0] == '}' {
+			return line[1:], nil
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || len(line) < eq+2 || line[eq+1] != '"' {
+			return "", fmt.Errorf("bad label in %q", line)
+		}
+		name := strings.TrimSpace(line[:eq])
+		line = line[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(line); i++ {
+			ch := line[i]
+			if ch == '\\' && i+1 < len(line) {
+				i++
+				switch line[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(line[i])
+				}
+				continue
+			}
+			if ch == '"' {
+				line, closed = line[i+1:], true
+				break
+			}
+			value.WriteByte(ch)
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated value of label %s", name)
+		}
+		labels[name] = value.String()
+	}
+}
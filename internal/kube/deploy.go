@@ -10,7 +10,7 @@ import (
 
 // RestartDeployment triggers a rollout restart of the deckhouse deployment.
 func (c *Client) RestartDeployment(ctx context.Context) error {
-	deploy, err := c.cs.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+	deploy, err := c.cs.AppsV1().Deployments(c.opts.Namespace).Get(ctx, c.opts.Deployment, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("get deployment: %w", err)
 	}
@@ -20,7 +20,7 @@ func (c *Client) RestartDeployment(ctx context.Context) error {
 	}
 	deploy.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
 
-	_, err = c.cs.AppsV1().Deployments(namespace).Update(ctx, deploy, metav1.UpdateOptions{})
+	_, err = c.cs.AppsV1().Deployments(c.opts.Namespace).Update(ctx, deploy, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("update deployment: %w", err)
 	}
@@ -9,14 +9,14 @@ import (
 )
 
 func (c *Client) fetchRegistryCreds(ctx context.Context) (*RegistryCreds, error) {
-	secret, err := c.cs.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	secret, err := c.cs.CoreV1().Secrets(c.opts.Namespace).Get(ctx, c.opts.Secret, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	raw := secret.Data[".dockerconfigjson"]
 	if len(raw) == 0 {
-		return nil, fmt.Errorf("empty .dockerconfigjson in secret %s", secretName)
+		return nil, fmt.Errorf("empty .dockerconfigjson in secret %s", c.opts.Secret)
 	}
 
 	var cfg struct {
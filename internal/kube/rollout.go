@@ -0,0 +1,170 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// stallReasons are container waiting reasons that will not resolve by
+// themselves. ErrImagePull is not one: the kubelet retries and turns it into
+// ImagePullBackOff if the pull keeps failing.
+var stallReasons = map[string]bool{
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// RolloutStatus is a snapshot of the deckhouse Deployment rollout.
+type RolloutStatus struct {
+	Done    bool   // new pods are Running and Ready, old ones are gone
+	Message string // progress description while not done
+	Stalled string // why the rollout cannot progress; empty unless stuck
+
+	// NewPod is a pod of the current ReplicaSet, nil until one exists.
+	NewPod *RolloutPod
+}
+
+// RolloutPod is a pod created by the current ReplicaSet.
+type RolloutPod struct {
+	Name          string
+	Image         string
+	RunningDigest string
+	Ready         bool
+}
+
+// RolloutStatus checks the Deployment, its current ReplicaSet and that
+// ReplicaSet's pods, similar to "kubectl rollout status".
+func (c *Client) RolloutStatus(ctx context.Context) (*RolloutStatus, error) {
+	deploy, err := c.cs.AppsV1().Deployments(c.opts.Namespace).Get(ctx, c.opts.Deployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get deployment: %w", err)
+	}
+
+	st := &RolloutStatus{}
+
+	// Until the controller observes the new generation, the conditions are
+	// those of the previous rollout.
+	if deploy.Generation > deploy.Status.ObservedGeneration {
+		st.Message = "waiting for the deployment update to be observed"
+		return st, nil
+	}
+
+	for _, cond := range deploy.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			st.Stalled = fmt.Sprintf("progress deadline exceeded: %s", cond.Message)
+			return st, nil
+		}
+	}
+
+	pods, err := c.currentPods(ctx, deploy)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if reason := podStallReason(pod); reason != "" {
+			st.Stalled = fmt.Sprintf("pod %s: %s", pod.Name, reason)
+			return st, nil
+		}
+		if st.NewPod == nil || (!st.NewPod.Ready && isPodReady(pod)) {
+			st.NewPod = c.newRolloutPod(pod)
+		}
+	}
+
+	want := int32(1)
+	if deploy.Spec.Replicas != nil {
+		want = *deploy.Spec.Replicas
+	}
+	s := deploy.Status
+	switch {
+	case s.UpdatedReplicas < want:
+		st.Message = fmt.Sprintf("%d of %d new replicas updated", s.UpdatedReplicas, want)
+	case s.Replicas > s.UpdatedReplicas:
+		st.Message = fmt.Sprintf("%d old replicas pending termination", s.Replicas-s.UpdatedReplicas)
+	case s.AvailableReplicas < s.UpdatedReplicas:
+		st.Message = fmt.Sprintf("%d of %d updated replicas available", s.AvailableReplicas, s.UpdatedReplicas)
+	case st.NewPod == nil || !st.NewPod.Ready:
+		st.Message = "waiting for the new pod to become Ready"
+	default:
+		st.Done = true
+	}
+	return st, nil
+}
+
+// currentPods returns the pods of the ReplicaSet matching the Deployment's current revision.
+func (c *Client) currentPods(ctx context.Context, deploy *appsv1.Deployment) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("deployment selector: %w", err)
+	}
+
+	rsList, err := c.cs.AppsV1().ReplicaSets(c.opts.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("list replicasets: %w", err)
+	}
+
+	revision := deploy.Annotations[revisionAnnotation]
+	var hash string
+	for _, rs := range rsList.Items {
+		if metav1.IsControlledBy(&rs, deploy) && rs.Annotations[revisionAnnotation] == revision {
+			hash = rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+			break
+		}
+	}
+	if hash == "" {
+		return nil, nil // the controller has not created it yet
+	}
+
+	pods, err := c.cs.CoreV1().Pods(c.opts.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: appsv1.DefaultDeploymentUniqueLabelKey + "=" + hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+func podStallReason(pod *corev1.Pod) string {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if w := cs.State.Waiting; w != nil && stallReasons[w.Reason] {
+			msg := fmt.Sprintf("container %s: %s", cs.Name, w.Reason)
+			if w.Message != "" {
+				msg += " (" + w.Message + ")"
+			}
+			return msg
+		}
+	}
+	return ""
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// newRolloutPod describes pod by its deckhouse container, chosen as in
+// podInfo.
+func (c *Client) newRolloutPod(pod *corev1.Pod) *RolloutPod {
+	rp := &RolloutPod{Name: pod.Name, Ready: isPodReady(pod)}
+	info := c.podInfo(pod)
+	if main := info.Main(); main != nil {
+		rp.Image, rp.RunningDigest = main.Image, main.Digest
+	}
+	return rp
+}
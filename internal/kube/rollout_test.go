@@ -0,0 +1,146 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testHash = "7d9f8"
+
+// rolloutFixture is a deckhouse Deployment at revision 2 whose current
+// ReplicaSet has one pod.
+func rolloutFixture() (*appsv1.Deployment, *appsv1.ReplicaSet, *corev1.Pod) {
+	one := int32(1)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: DefaultDeployment, Namespace: DefaultNamespace, UID: "deploy-uid", Generation: 2,
+			Annotations: map[string]string{revisionAnnotation: "2"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &one,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "deckhouse"}},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "deckhouse-" + testHash, Namespace: DefaultNamespace,
+		Labels:          map[string]string{"app": "deckhouse", appsv1.DefaultDeploymentUniqueLabelKey: testHash},
+		Annotations:     map[string]string{revisionAnnotation: "2"},
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deploy, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "deckhouse-" + testHash + "-abcde", Namespace: DefaultNamespace,
+			Labels: map[string]string{"app": "deckhouse", appsv1.DefaultDeploymentUniqueLabelKey: testHash},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "deckhouse", Image: "registry.example.com/sys/deckhouse-oss:pr42"}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "deckhouse", Ready: true, ImageID: "registry.example.com/sys/deckhouse-oss@sha256:main"}},
+		},
+	}
+	return deploy, rs, pod
+}
+
+func rolloutStatus(t *testing.T, deploy *appsv1.Deployment, rs *appsv1.ReplicaSet, pod *corev1.Pod) *RolloutStatus {
+	t.Helper()
+	c := NewClientFromClientset(fake.NewSimpleClientset(deploy, rs, pod), Options{})
+	st, err := c.RolloutStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestRolloutStatus(t *testing.T) {
+	deadline := appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded", Message: "ReplicaSet has timed out progressing"}
+	waiting := func(reason string) func(*appsv1.Deployment, *corev1.Pod) {
+		return func(_ *appsv1.Deployment, pod *corev1.Pod) {
+			pod.Status.Conditions[0].Status = corev1.ConditionFalse
+			pod.Status.ContainerStatuses[0].Ready = false
+			pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: reason}
+		}
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(*appsv1.Deployment, *corev1.Pod)
+		wantDone    bool
+		wantStalled string // substring; empty means not stalled
+		wantMessage string // substring
+	}{
+		{
+			name:     "done",
+			setup:    func(*appsv1.Deployment, *corev1.Pod) {},
+			wantDone: true,
+		},
+		{
+			// Right after a restart the condition of the previous rollout is still there.
+			name: "stale deadline condition before the new generation is observed",
+			setup: func(d *appsv1.Deployment, _ *corev1.Pod) {
+				d.Generation = 3
+				d.Status.Conditions = []appsv1.DeploymentCondition{deadline}
+			},
+			wantMessage: "waiting for the deployment update to be observed",
+		},
+		{
+			name: "deadline exceeded for the observed generation",
+			setup: func(d *appsv1.Deployment, _ *corev1.Pod) {
+				d.Status.Conditions = []appsv1.DeploymentCondition{deadline}
+			},
+			wantStalled: "progress deadline exceeded",
+		},
+		{
+			name:        "image pull backing off",
+			setup:       waiting("ImagePullBackOff"),
+			wantStalled: "container deckhouse: ImagePullBackOff",
+		},
+		{
+			name:        "image pull error is retried",
+			setup:       waiting("ErrImagePull"),
+			wantMessage: "waiting for the new pod to become Ready",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy, rs, pod := rolloutFixture()
+			tt.setup(deploy, pod)
+			st := rolloutStatus(t, deploy, rs, pod)
+
+			if st.Done != tt.wantDone {
+				t.Errorf("Done = %v, want %v (message %q, stalled %q)", st.Done, tt.wantDone, st.Message, st.Stalled)
+			}
+			if (tt.wantStalled == "") != (st.Stalled == "") || !strings.Contains(st.Stalled, tt.wantStalled) {
+				t.Errorf("Stalled = %q, want %q", st.Stalled, tt.wantStalled)
+			}
+			if !strings.Contains(st.Message, tt.wantMessage) {
+				t.Errorf("Message = %q, want %q", st.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRolloutPodSidecarFirst(t *testing.T) {
+	deploy, rs, pod := rolloutFixture()
+	sidecar := corev1.Container{Name: "kube-rbac-proxy", Image: "registry.example.com/sys/kube-rbac-proxy:v0.18"}
+	pod.Spec.Containers = append([]corev1.Container{sidecar}, pod.Spec.Containers...)
+	pod.Status.ContainerStatuses = append([]corev1.ContainerStatus{
+		{Name: "kube-rbac-proxy", Ready: true, ImageID: "registry.example.com/sys/kube-rbac-proxy@sha256:sidecar"},
+	}, pod.Status.ContainerStatuses...)
+
+	st := rolloutStatus(t, deploy, rs, pod)
+	if st.NewPod == nil {
+		t.Fatal("no new pod")
+	}
+	if st.NewPod.Image != "registry.example.com/sys/deckhouse-oss:pr42" || st.NewPod.RunningDigest != "sha256:main" {
+		t.Errorf("new pod = %+v, want the deckhouse container", st.NewPod)
+	}
+}
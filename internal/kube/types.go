@@ -2,12 +2,59 @@ package kube
 
 import "time"
 
+// Defaults for a standard Deckhouse installation.
 const (
-	namespace      = "d8-system"
-	deploymentName = "deckhouse"
-	secretName     = "deckhouse-registry"
+	DefaultNamespace  = "d8-system"
+	DefaultDeployment = "deckhouse"
+	DefaultSecret     = "deckhouse-registry"
+	DefaultSelector   = "app=deckhouse"
 )
 
+const (
+	// RepoAnnotation on the deckhouse Deployment names the GitHub repository
+	// ("owner/name") the running image was built from.
+	RepoAnnotation = "deckhouse-status/github-repo"
+
+	// CommitAnnotation on the deckhouse Deployment records the source commit
+	// SHA of the deployed image, for images built without OCI labels.
+	CommitAnnotation = "deckhouse-status/commit"
+)
+
+// Options selects the cluster and the Deckhouse installation to inspect.
+// Empty fields fall back to the kubeconfig loading rules and the defaults above.
+type Options struct {
+	Kubeconfig string // explicit kubeconfig path; empty means $KUBECONFIG or ~/.kube/config
+	Context    string // kubeconfig context; empty means current-context
+	Namespace  string
+	Deployment string
+	Secret     string // dockerconfigjson secret with registry credentials
+	Selector   string // label selector of the deckhouse pods
+	// ControllerPort is the port of the deckhouse-controller HTTP server,
+	// reached through the API server pod proxy.
+	ControllerPort string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Namespace == "" {
+		o.Namespace = DefaultNamespace
+	}
+	if o.Deployment == "" {
+		o.Deployment = DefaultDeployment
+	}
+	if o.Secret == "" {
+		o.Secret = DefaultSecret
+	}
+	if o.Selector == "" {
+		o.Selector = DefaultSelector
+	}
+	if o.ControllerPort == "" {
+		o.ControllerPort = DefaultControllerPort
+	}
+	return o
+}
+
+// ClusterInfo describes the deckhouse pods. The image and pod fields are those
+// of the primary pod, the first Running one; Pods has every replica.
 type ClusterInfo struct {
 	Image         string // full image reference (e.g., "dev-registry.deckhouse.io/sys/deckhouse-oss:pr15160")
 	Registry      string // registry host (e.g., "dev-registry.deckhouse.io")
@@ -17,7 +64,79 @@ type ClusterInfo struct {
 	PodCreated    time.Time
 	PodPhase      string
 	RunningDigest string // e.g., "sha256:3778e43a..."
+	Platform      string // "os/arch" of the node running the pod, empty if the node cannot be read
 	RegistryCreds *RegistryCreds
+	GitHubRepo    string // from RepoAnnotation on the Deployment, empty if not set
+	Commit        string // from CommitAnnotation on the Deployment, empty if not set
+
+	// Pods are all pods matching the selector, sorted by name.
+	Pods []PodInfo
+	// Events are the recent Warning events of the primary pod, newest
+	// first. Empty if there are none or they cannot be read.
+	Events []Event
+}
+
+// PodInfo is one deckhouse replica.
+type PodInfo struct {
+	Name    string
+	Node    string
+	Phase   string
+	Created time.Time
+	Ready   bool // the pod's Ready condition
+	// Containers are the init containers followed by the regular ones, in
+	// spec order.
+	Containers []ContainerInfo
+}
+
+// ContainerInfo is one container of a pod and its status.
+type ContainerInfo struct {
+	Name     string
+	Init     bool
+	Main     bool   // the deckhouse container; ClusterInfo's image fields describe it
+	Image    string // as in the pod spec
+	Digest   string // of the running image, empty until pulled
+	Ready    bool
+	Restarts int32
+	// Waiting is the reason the container is not running, e.g.
+	// "CrashLoopBackOff" or "ImagePullBackOff"; empty while it runs.
+	Waiting         string
+	WaitingMessage  string
+	LastTermination *Termination // previous run, nil if it never restarted
+}
+
+// Termination describes how a container run ended.
+type Termination struct {
+	Reason     string // e.g. "Error", "OOMKilled"
+	ExitCode   int32
+	FinishedAt time.Time
+}
+
+// Event is a Kubernetes event, aggregated by the event recorder.
+type Event struct {
+	Reason   string // e.g. "BackOff", "Unhealthy"
+	Message  string
+	Count    int32
+	LastSeen time.Time
+}
+
+// Primary returns the primary pod, nil if there are no pods.
+func (c *ClusterInfo) Primary() *PodInfo {
+	for i := range c.Pods {
+		if c.Pods[i].Name == c.PodName {
+			return &c.Pods[i]
+		}
+	}
+	return nil
+}
+
+// Main returns the deckhouse container of the pod, nil if it has none.
+func (p *PodInfo) Main() *ContainerInfo {
+	for i := range p.Containers {
+		if p.Containers[i].Main {
+			return &p.Containers[i]
+		}
+	}
+	return nil
 }
 
 type RegistryCreds struct {
@@ -11,15 +11,14 @@ import (
 	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
 )
 
-func fetchToken(ctx context.Context, host, repo string, creds *kube.RegistryCreds) (token string, err error) {
+func (c *Client) fetchToken(ctx context.Context, host, repo string, creds *kube.RegistryCreds) (token string, err error) {
 	// Hit /v2/ to get WWW-Authenticate parameters (realm, service)
-	checkURL := fmt.Sprintf("https://%s/v2/", host)
-	req, err := http.NewRequestWithContext(ctx, "GET", checkURL, nil)
+	req, err := c.newRequest(ctx, "GET", c.url(host, "/v2/"), "")
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("cannot reach registry: %w", err)
 	}
@@ -53,7 +52,7 @@ func fetchToken(ctx context.Context, host, repo string, creds *kube.RegistryCred
 
 	tokenURL := realm + "?" + q.Encode()
 
-	tokenReq, err := http.NewRequestWithContext(ctx, "GET", tokenURL, nil)
+	tokenReq, err := c.newRequest(ctx, "GET", tokenURL, "")
 	if err != nil {
 		return "", err
 	}
@@ -61,7 +60,7 @@ func fetchToken(ctx context.Context, host, repo string, creds *kube.RegistryCred
 		tokenReq.Header.Set("Authorization", "Basic "+creds.Auth)
 	}
 
-	tokenResp, err := http.DefaultClient.Do(tokenReq)
+	tokenResp, err := c.httpClient.Do(tokenReq)
 	if err != nil {
 		return "", fmt.Errorf("token request failed: %w", err)
 	}
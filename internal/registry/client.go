@@ -2,25 +2,112 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/glitchy-sheep/deckhouse-status/internal/httpretry"
 	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
 )
 
 var ErrTagNotFound = errors.New("tag not found")
 
+// DefaultUserAgent is sent when Options.UserAgent is empty.
+const DefaultUserAgent = "deckhouse-status"
+
+// Options configures a Client. Empty fields take the defaults.
+type Options struct {
+	BaseURL    string       // registry root overriding https://<host>, e.g. a test server
+	HTTPClient *http.Client // default httpretry.Client
+	UserAgent  string       // default DefaultUserAgent
+}
+
+// Client talks to Docker Registry v2 APIs. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+}
+
+// NewClient creates a registry client.
+func NewClient(opts Options) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(opts.BaseURL, "/"),
+		httpClient: opts.HTTPClient,
+		userAgent:  opts.UserAgent,
+	}
+	if c.httpClient == nil {
+		c.httpClient = httpretry.Client
+	}
+	if c.userAgent == "" {
+		c.userAgent = DefaultUserAgent
+	}
+	return c
+}
+
+// url returns the API URL of path on host.
+func (c *Client) url(host, path string) string {
+	if c.baseURL != "" {
+		return c.baseURL + path
+	}
+	return "https://" + host + path
+}
+
+// newRequest creates a request with the User-Agent and, if set, the bearer token.
+func (c *Client) newRequest(ctx context.Context, method, url, token string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// Manifest media types negotiated with the registry.
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+var manifestAccept = strings.Join([]string{
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+}, ", ")
+
+// IsIndex reports whether mediaType is a multi-platform index (OCI index or Docker manifest list).
+func IsIndex(mediaType string) bool {
+	return mediaType == MediaTypeOCIIndex || mediaType == MediaTypeDockerManifestList
+}
+
 type Result struct {
 	TagExists   bool   // true if registry tag still exists
 	Digest      string // digest from registry (when tag exists)
-	DigestMatch bool   // true if registry digest matches running digest
+	MediaType   string // media type of the tag's manifest
+	DigestMatch bool   // true if registry digest matches running digest, directly or as a platform child of an index
+	Platform    string // "os/arch[/variant]" of the matching index child, empty for a direct match
 	ImageExists bool   // true if image blob still exists by digest (when tag is gone)
-	Err         error
+
+	// From the running image's config labels; empty when the image has none.
+	Revision string    // org.opencontainers.image.revision: source commit SHA
+	Created  time.Time // org.opencontainers.image.created, or the config's created time
+
+	Err error
 }
 
-// Check verifies the image tag in registry and compares digests.
-func Check(ctx context.Context, host, repo, tag, runningDigest string, creds *kube.RegistryCreds) *Result {
+// Check verifies the image tag in registry and compares digests. platform is
+// the "os/arch" of the node running the image; when the runtime reports the
+// digest of an index, the labels are read from that platform's image.
+func (c *Client) Check(ctx context.Context, host, repo, tag, runningDigest, platform string, creds *kube.RegistryCreds) *Result {
 	r := &Result{}
 
 	if host == "" || repo == "" || tag == "" {
@@ -29,51 +116,77 @@ func Check(ctx context.Context, host, repo, tag, runningDigest string, creds *ku
 	}
 
 	// Get Bearer token for this repository
-	token, err := fetchToken(ctx, host, repo, creds)
+	token, err := c.fetchToken(ctx, host, repo, creds)
 	if err != nil {
 		r.Err = fmt.Errorf("registry auth: %w", err)
 		return r
 	}
 
+	c.checkTag(ctx, r, host, repo, tag, runningDigest, token)
+
+	// Labels are best-effort: images built without them are common.
+	if r.Err == nil && runningDigest != "" && (r.TagExists || r.ImageExists) {
+		if labels, err := c.fetchImageLabels(ctx, host, repo, runningDigest, platform, token); err == nil {
+			r.Revision = labels.Revision
+			r.Created = labels.Created
+		}
+	}
+
+	return r
+}
+
+// checkTag fills the tag and digest fields of r.
+func (c *Client) checkTag(ctx context.Context, r *Result, host, repo, tag, runningDigest, token string) {
 	// Check if tag exists and get its digest
-	digest, err := fetchManifestDigest(ctx, host, repo, tag, token)
+	digest, mediaType, err := c.fetchManifestDigest(ctx, host, repo, tag, token)
 	if err == nil {
+		match, platform := digest == runningDigest, ""
+
+		// The container runtime records the digest of the platform manifest it
+		// pulled, not of the index the tag points to. The tag is reported only
+		// once that is known: an unreadable index is not a newer image.
+		if !match && runningDigest != "" && IsIndex(mediaType) {
+			child, found, err := c.findIndexChild(ctx, host, repo, digest, token, func(m indexChild) bool { return m.Digest == runningDigest })
+			if err != nil {
+				r.Err = fmt.Errorf("read image index: %w", err)
+				return
+			}
+			match, platform = found, child.Platform
+		}
+
 		r.TagExists = true
 		r.Digest = digest
-		r.DigestMatch = digest == runningDigest
-		return r
+		r.MediaType = mediaType
+		r.DigestMatch = match
+		r.Platform = platform
+		return
 	}
 
 	if !errors.Is(err, ErrTagNotFound) {
 		r.Err = err
-		return r
+		return
 	}
 
 	// Tag not found — check if image still exists by its running digest
 	r.TagExists = false
 	if runningDigest != "" {
-		_, err := fetchManifestDigest(ctx, host, repo, runningDigest, token)
+		_, _, err := c.fetchManifestDigest(ctx, host, repo, runningDigest, token)
 		r.ImageExists = err == nil
 	}
-
-	return r
 }
 
-func fetchManifestDigest(ctx context.Context, host, repo, reference, token string) (digest string, err error) {
-	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, reference)
+func (c *Client) fetchManifestDigest(ctx context.Context, host, repo, reference, token string) (digest, mediaType string, err error) {
+	manifestURL := c.url(host, fmt.Sprintf("/v2/%s/manifests/%s", repo, reference))
 
-	req, err := http.NewRequestWithContext(ctx, "HEAD", manifestURL, nil)
+	req, err := c.newRequest(ctx, "HEAD", manifestURL, token)
 	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json")
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
+		return "", "", err
 	}
+	req.Header.Set("Accept", manifestAccept)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return "", "", fmt.Errorf("request failed: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
@@ -82,16 +195,90 @@ func fetchManifestDigest(ctx context.Context, host, repo, reference, token strin
 	}()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return "", ErrTagNotFound
+		return "", "", ErrTagNotFound
 	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		return "", "", fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
 	digest = resp.Header.Get("Docker-Content-Digest")
 	if digest == "" {
-		return "", fmt.Errorf("no Docker-Content-Digest header")
+		return "", "", fmt.Errorf("no Docker-Content-Digest header")
 	}
 
-	return digest, nil
+	mediaType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+	return digest, strings.TrimSpace(mediaType), nil
+}
+
+// imageIndex is the subset of an OCI index / Docker manifest list we need.
+type imageIndex struct {
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// indexChild is a platform manifest listed in an index.
+type indexChild struct {
+	Digest   string
+	Platform string // "os/arch[/variant]"
+}
+
+// find returns the first manifest of the index that match accepts.
+func (index *imageIndex) find(match func(indexChild) bool) (indexChild, bool) {
+	for _, m := range index.Manifests {
+		child := indexChild{Digest: m.Digest, Platform: m.Platform.OS + "/" + m.Platform.Architecture}
+		if m.Platform.Variant != "" {
+			child.Platform += "/" + m.Platform.Variant
+		}
+		if match(child) {
+			return child, true
+		}
+	}
+	return indexChild{}, false
+}
+
+// findIndexChild fetches the index at indexDigest and returns the first of its
+// manifests that match accepts.
+func (c *Client) findIndexChild(ctx context.Context, host, repo, indexDigest, token string, match func(indexChild) bool) (indexChild, bool, error) {
+	var index imageIndex
+	if err := c.getManifest(ctx, host, repo, indexDigest, token, &index); err != nil {
+		return indexChild{}, false, err
+	}
+	child, found := index.find(match)
+	return child, found, nil
+}
+
+// getManifest GETs a manifest by reference and decodes it into target.
+func (c *Client) getManifest(ctx context.Context, host, repo, reference, token string, target any) (err error) {
+	manifestURL := c.url(host, fmt.Sprintf("/v2/%s/manifests/%s", repo, reference))
+
+	req, err := c.newRequest(ctx, "GET", manifestURL, token)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", manifestAccept)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close registry response: %w", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("cannot decode manifest: %w", err)
+	}
+	return nil
 }
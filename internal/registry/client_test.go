@@ -0,0 +1,107 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/fakeapi"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+)
+
+const (
+	host = "dev-registry.example.com"
+	repo = "sys/deckhouse-oss"
+	tag  = "pr42"
+)
+
+var built = time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		index bool
+		// runIndex makes the pod run the index digest, as some runtimes report.
+		runIndex bool
+		platform string // of the node
+		// newer pushes another image to the tag after the running one.
+		newer     bool
+		deleteTag bool
+
+		wantTagExists   bool
+		wantMatch       bool
+		wantPlatform    string
+		wantImageExists bool
+		wantRevision    string // default "aaaa"
+	}{
+		{name: "manifest matches", wantTagExists: true, wantMatch: true},
+		{name: "index child matches", index: true, wantTagExists: true, wantMatch: true, wantPlatform: "linux/amd64"},
+		{name: "running the index", index: true, runIndex: true, wantTagExists: true, wantMatch: true},
+		{name: "running the index on amd64", index: true, runIndex: true, platform: "linux/amd64", wantTagExists: true, wantMatch: true},
+		{name: "running the index on arm64", index: true, runIndex: true, platform: "linux/arm64", wantTagExists: true, wantMatch: true, wantRevision: "aaaa-arm64"},
+		{name: "tag moved", newer: true, wantTagExists: true},
+		{name: "tag moved from index", index: true, newer: true, wantTagExists: true},
+		{name: "tag deleted", deleteTag: true, wantImageExists: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := fakeapi.NewRegistry(t)
+			reg.Auth = fakeapi.BasicAuth("user", "pass")
+			running := reg.Push(repo, tag, fakeapi.Image{Revision: "aaaa", Created: built, Index: tt.index})
+			if tt.runIndex {
+				running = reg.TagDigest(repo, tag)
+			}
+			if tt.newer {
+				reg.Push(repo, tag, fakeapi.Image{Revision: "bbbb", Created: built.Add(time.Hour), Index: tt.index})
+			}
+			if tt.deleteTag {
+				reg.DeleteTag(repo, tag)
+			}
+
+			c := registry.NewClient(registry.Options{BaseURL: reg.URL})
+			r := c.Check(context.Background(), host, repo, tag, running, tt.platform, &kube.RegistryCreds{Auth: reg.Auth})
+			if r.Err != nil {
+				t.Fatal(r.Err)
+			}
+			if r.TagExists != tt.wantTagExists || r.DigestMatch != tt.wantMatch || r.Platform != tt.wantPlatform || r.ImageExists != tt.wantImageExists {
+				t.Errorf("got tag=%v match=%v platform=%q image=%v, want tag=%v match=%v platform=%q image=%v",
+					r.TagExists, r.DigestMatch, r.Platform, r.ImageExists,
+					tt.wantTagExists, tt.wantMatch, tt.wantPlatform, tt.wantImageExists)
+			}
+			wantRevision := tt.wantRevision
+			if wantRevision == "" {
+				wantRevision = "aaaa"
+			}
+			if r.Revision != wantRevision || !r.Created.Equal(built) {
+				t.Errorf("labels = %q %s, want %s %s", r.Revision, r.Created, wantRevision, built)
+			}
+		})
+	}
+}
+
+func TestCheckUnreadableIndex(t *testing.T) {
+	reg := fakeapi.NewRegistry(t)
+	running := reg.Push(repo, tag, fakeapi.Image{Revision: "aaaa", Created: built, Index: true})
+	reg.Push(repo, tag, fakeapi.Image{Revision: "bbbb", Created: built.Add(time.Hour), Index: true})
+	reg.Corrupt(reg.TagDigest(repo, tag))
+
+	c := registry.NewClient(registry.Options{BaseURL: reg.URL})
+	r := c.Check(context.Background(), host, repo, tag, running, "", nil)
+	if r.Err == nil || r.TagExists || r.Digest != "" || r.DigestMatch {
+		t.Errorf("got tag=%v digest=%q match=%v err=%v, want only an index error", r.TagExists, r.Digest, r.DigestMatch, r.Err)
+	}
+}
+
+func TestCheckBadCredentials(t *testing.T) {
+	reg := fakeapi.NewRegistry(t)
+	reg.Auth = fakeapi.BasicAuth("user", "pass")
+	running := reg.Push(repo, tag, fakeapi.Image{Created: built})
+
+	c := registry.NewClient(registry.Options{BaseURL: reg.URL})
+	r := c.Check(context.Background(), host, repo, tag, running, "", &kube.RegistryCreds{Auth: fakeapi.BasicAuth("user", "wrong")})
+	if r.Err == nil {
+		t.Fatalf("got %+v, want an auth error", r)
+	}
+}
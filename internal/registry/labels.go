@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OCI annotation keys read from the image config labels.
+const (
+	LabelRevision = "org.opencontainers.image.revision"
+	LabelCreated  = "org.opencontainers.image.created"
+)
+
+type imageLabels struct {
+	Revision string
+	Created  time.Time
+}
+
+// defaultPlatform is the platform whose image config is read when the running
+// digest is that of an index and the node platform is unknown.
+const defaultPlatform = "linux/amd64"
+
+// imageManifest is the subset of a single-platform manifest we need. An index
+// decodes into it too, with Manifests set instead of Config.
+type imageManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	imageIndex
+}
+
+// imageConfig is the subset of the image config blob we need.
+type imageConfig struct {
+	Created string `json:"created"`
+	Config  struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+// fetchImageLabels reads the source revision and build time of the image with
+// the given manifest digest from its config blob. The runtime may report the
+// digest of an index; its image for platform is read then.
+func (c *Client) fetchImageLabels(ctx context.Context, host, repo, digest, platform, token string) (imageLabels, error) {
+	var manifest imageManifest
+	if err := c.getManifest(ctx, host, repo, digest, token, &manifest); err != nil {
+		return imageLabels{}, err
+	}
+	if len(manifest.Manifests) > 0 {
+		if platform == "" {
+			platform = defaultPlatform
+		}
+		child, found := manifest.find(func(m indexChild) bool { return m.Platform == platform })
+		if !found {
+			return imageLabels{}, fmt.Errorf("index %s has no %s image", digest, platform)
+		}
+		digest, manifest = child.Digest, imageManifest{}
+		if err := c.getManifest(ctx, host, repo, digest, token, &manifest); err != nil {
+			return imageLabels{}, err
+		}
+	}
+	if manifest.Config.Digest == "" {
+		return imageLabels{}, fmt.Errorf("manifest %s has no config", digest)
+	}
+
+	var cfg imageConfig
+	if err := c.getBlob(ctx, host, repo, manifest.Config.Digest, token, &cfg); err != nil {
+		return imageLabels{}, err
+	}
+
+	labels := imageLabels{Revision: cfg.Config.Labels[LabelRevision]}
+	created := cfg.Config.Labels[LabelCreated]
+	if created == "" {
+		created = cfg.Created
+	}
+	if t, err := time.Parse(time.RFC3339, created); err == nil {
+		labels.Created = t
+	}
+	return labels, nil
+}
+
+// getBlob GETs a blob by digest and decodes it into target.
+// Registries often redirect blob downloads to object storage; the Authorization
+// header is not forwarded to a different host.
+func (c *Client) getBlob(ctx context.Context, host, repo, digest, token string, target any) (err error) {
+	blobURL := c.url(host, fmt.Sprintf("/v2/%s/blobs/%s", repo, digest))
+
+	req, err := c.newRequest(ctx, "GET", blobURL, token)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close registry response: %w", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("cannot decode blob: %w", err)
+	}
+	return nil
+}
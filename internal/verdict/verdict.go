@@ -0,0 +1,337 @@
+// Package verdict decides whether the running Deckhouse image is up to date.
+//
+// It is the single place that combines cluster, GitHub and registry data into
+// a status; renderers only format the returned Verdict.
+package verdict
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+)
+
+// Kind is the deployment state.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	UpToDate
+	Outdated
+	Building
+	WaitingForCI
+	BuildFailed
+	MixedRollout // replicas run different images
+)
+
+var kindNames = map[Kind]string{
+	Unknown:      "unknown",
+	UpToDate:     "up_to_date",
+	Outdated:     "outdated",
+	Building:     "building",
+	WaitingForCI: "waiting_for_ci",
+	BuildFailed:  "build_failed",
+	MixedRollout: "mixed_rollout",
+}
+
+var kindTitles = map[Kind]string{
+	Unknown:      "Cannot determine",
+	UpToDate:     "Up to date",
+	Outdated:     "Outdated",
+	Building:     "Building",
+	WaitingForCI: "Waiting for CI",
+	BuildFailed:  "Build failed",
+	MixedRollout: "Mixed rollout",
+}
+
+// String returns the stable machine-readable name, e.g. "up_to_date".
+func (k Kind) String() string { return kindNames[k] }
+
+// Title returns the human-readable name, e.g. "Up to date".
+func (k Kind) Title() string { return kindTitles[k] }
+
+// Source is the evidence a verdict is based on.
+type Source string
+
+const (
+	SourceNone     Source = "none"
+	SourceRegistry Source = "registry" // running digest compared with the registry tag
+	SourceBuild    Source = "build"    // pod creation time compared with the CI build
+	SourceCommit   Source = "commit"   // deployed commit (image labels) compared with the PR or branch head
+	SourceRelease  Source = "release"  // release tag compared with the newest patch release
+	SourceCluster  Source = "cluster"  // running digests of the replicas compared with each other
+)
+
+// Action is a suggested next step for the user.
+type Action string
+
+const (
+	ActionNone       Action = ""
+	ActionRestart    Action = "restart pod to update"
+	ActionCheckBuild Action = "check CI build logs"
+	ActionUpgrade    Action = "switch to the latest patch release"
+	ActionRollout    Action = "wait for the rollout or restart the old replicas"
+	ActionCheckPod   Action = "check the pod logs and events"
+)
+
+// Verdict is the outcome of Evaluate.
+type Verdict struct {
+	Kind   Kind
+	Reason string
+	Source Source
+	Action Action
+	// Health is a problem of the primary pod, e.g. "CrashLoopBackOff"; empty
+	// if it is healthy. It does not change Kind: an up-to-date image may
+	// still crashloop.
+	Health       string
+	HealthReason string // e.g. "deckhouse: 12 restarts, last exit: Error (code 1)"
+}
+
+// Input is everything Evaluate looks at. Head, Release and Registry may be
+// nil when the corresponding data source was skipped, failed or does not
+// apply to the image tag.
+type Input struct {
+	Cluster *kube.ClusterInfo
+	// Head is the PR or branch the image is built from.
+	Head *github.HeadBuild
+	// Release is set for release tags.
+	Release  *github.ReleaseInfo
+	Registry *registry.Result
+	// DeployedSHA is the source commit of the running image, empty if unknown.
+	DeployedSHA string
+	// Compare is DeployedSHA...Head, nil if not fetched.
+	Compare *github.Comparison
+	// HeadName is how reasons refer to Head, e.g. "PR head" or "main";
+	// defaults to "head".
+	HeadName string
+	// Location is used to format times in the reason; defaults to UTC.
+	Location *time.Location
+}
+
+// Evaluate computes the verdict.
+//
+// The registry digest comparison is preferred. While a build is running its
+// result is not in the registry yet, so build state wins; when the tag is gone
+// (registry GC) the deployed commit is compared with the head if known,
+// otherwise pod creation time with the build completion time. A release
+// that matches its tag is still outdated when a newer patch is out. Replicas
+// running different images override all of that: the primary pod alone does
+// not describe the cluster then.
+//
+// The health of the primary pod is judged separately and reported alongside.
+func Evaluate(in Input) Verdict {
+	v := evaluate(in)
+	v.Health, v.HealthReason = podHealth(in.Cluster)
+	if v.Health != "" && v.Action == ActionNone {
+		v.Action = ActionCheckPod
+	}
+	return v
+}
+
+func evaluate(in Input) Verdict {
+	if v, mixed := mixedRollout(in.Cluster); mixed {
+		return v
+	}
+	head, reg := in.Head, in.Registry
+
+	switch {
+	case buildActive(head):
+		return evaluateBuild(in)
+
+	case reg != nil && reg.Err == nil && reg.TagExists && reg.Digest != "":
+		if !reg.DigestMatch {
+			return Verdict{Kind: Outdated, Reason: "registry has newer image for tag", Source: SourceRegistry, Action: ActionRestart}
+		}
+		if in.Release != nil && in.Release.LatestPatch != in.Release.Tag {
+			return evaluateRelease(in.Release)
+		}
+		return Verdict{Kind: UpToDate, Reason: "digest matches registry", Source: SourceRegistry}
+
+	case head != nil && head.HeadSHA != "" && in.DeployedSHA != "":
+		return evaluateCommit(in)
+
+	case head != nil && head.BuildStatus != "":
+		return evaluateBuild(in)
+
+	case head != nil:
+		return Verdict{Kind: WaitingForCI, Reason: fmt.Sprintf("%s not started yet", head.BuildCheckName), Source: SourceBuild}
+
+	case in.Release != nil:
+		return evaluateRelease(in.Release)
+
+	case reg != nil && reg.Err != nil:
+		return Verdict{Kind: Unknown, Reason: fmt.Sprintf("registry: %s", reg.Err), Source: SourceRegistry}
+
+	default:
+		return Verdict{Kind: Unknown, Reason: "no registry tag, no build info", Source: SourceNone}
+	}
+}
+
+// mixedRollout reports replicas whose deckhouse container runs another image
+// than the newest replica, as in an unfinished rollout. Replicas that have
+// not pulled their image yet are skipped.
+func mixedRollout(c *kube.ClusterInfo) (Verdict, bool) {
+	if c == nil {
+		return Verdict{}, false
+	}
+
+	var newest *kube.PodInfo
+	for i := range c.Pods {
+		p := &c.Pods[i]
+		if m := p.Main(); m != nil && m.Digest != "" && (newest == nil || p.Created.After(newest.Created)) {
+			newest = p
+		}
+	}
+	if newest == nil {
+		return Verdict{}, false
+	}
+
+	total, other := 0, 0
+	for i := range c.Pods {
+		m := c.Pods[i].Main()
+		if m == nil || m.Digest == "" {
+			continue
+		}
+		total++
+		if m.Digest != newest.Main().Digest {
+			other++
+		}
+	}
+	if other == 0 {
+		return Verdict{}, false
+	}
+	return Verdict{
+		Kind:   MixedRollout,
+		Reason: fmt.Sprintf("%d of %d replicas run a different image than the newest replica %s", other, total, newest.Name),
+		Source: SourceCluster,
+		Action: ActionRollout,
+	}, true
+}
+
+func buildActive(head *github.HeadBuild) bool {
+	return head != nil && (head.BuildStatus == "in_progress" || head.BuildStatus == "queued")
+}
+
+func evaluateBuild(in Input) Verdict {
+	head := in.Head
+	buildName := head.BuildCheckName
+
+	loc := in.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	switch {
+	case buildActive(head):
+		return Verdict{Kind: Building, Reason: fmt.Sprintf("%s is running...", buildName), Source: SourceBuild}
+
+	case head.BuildConclusion == "success" && !head.BuildCompletedAt.IsZero():
+		podTime := in.Cluster.PodCreated
+		buildTime := head.BuildCompletedAt
+		podFmt := podTime.In(loc).Format("15:04")
+		buildFmt := buildTime.In(loc).Format("15:04")
+
+		if podTime.After(buildTime) {
+			return Verdict{Kind: UpToDate, Reason: fmt.Sprintf("pod created after %s: %s > %s", buildName, podFmt, buildFmt), Source: SourceBuild}
+		}
+		return Verdict{Kind: Outdated, Reason: fmt.Sprintf("%s completed after pod: %s > %s", buildName, buildFmt, podFmt), Source: SourceBuild, Action: ActionRestart}
+
+	case head.BuildConclusion == "failure":
+		return Verdict{Kind: BuildFailed, Reason: fmt.Sprintf("%s failed on last commit", buildName), Source: SourceBuild, Action: ActionCheckBuild}
+
+	default:
+		return Verdict{Kind: Unknown, Reason: fmt.Sprintf("%s status: %s", buildName, head.BuildStatus), Source: SourceBuild}
+	}
+}
+
+func evaluateCommit(in Input) Verdict {
+	head := in.Head
+
+	switch {
+	case in.DeployedSHA == head.HeadSHA:
+		return Verdict{Kind: UpToDate, Reason: "deployed commit is " + headName(in), Source: SourceCommit}
+	case head.BuildStatus == "":
+		return Verdict{Kind: WaitingForCI, Reason: fmt.Sprintf("%s not started yet", head.BuildCheckName), Source: SourceBuild}
+	case head.BuildConclusion == "failure":
+		return evaluateBuild(in)
+	default:
+		reason := fmt.Sprintf("deployed %s, %s %s", github.ShortSHA(in.DeployedSHA), headName(in), github.ShortSHA(head.HeadSHA))
+		if in.Compare != nil && in.Compare.AheadBy > 0 {
+			unit := "commits"
+			if in.Compare.AheadBy == 1 {
+				unit = "commit"
+			}
+			reason = fmt.Sprintf("deployed %s is %d %s behind %s", github.ShortSHA(in.DeployedSHA), in.Compare.AheadBy, unit, headName(in))
+		}
+		return Verdict{Kind: Outdated, Reason: reason, Source: SourceCommit, Action: ActionRestart}
+	}
+}
+
+func headName(in Input) string {
+	if in.HeadName != "" {
+		return in.HeadName
+	}
+	return "head"
+}
+
+func evaluateRelease(rel *github.ReleaseInfo) Verdict {
+	if rel.LatestPatch == rel.Tag {
+		return Verdict{Kind: UpToDate, Reason: rel.Tag + " is the latest patch", Source: SourceRelease}
+	}
+	return Verdict{Kind: Outdated, Reason: "latest patch is " + rel.LatestPatch, Source: SourceRelease, Action: ActionUpgrade}
+}
+
+// startingReasons are waiting reasons of containers that are about to run.
+var startingReasons = map[string]bool{
+	"ContainerCreating": true,
+	"PodInitializing":   true,
+}
+
+// podHealth reports the first problem of the primary pod, checking the
+// deckhouse container before the others: a container waiting for any reason
+// but being started (CrashLoopBackOff, ImagePullBackOff, ...), or a
+// restarted deckhouse container that is not ready.
+func podHealth(c *kube.ClusterInfo) (health, reason string) {
+	if c == nil {
+		return "", ""
+	}
+	pod := c.Primary()
+	if pod == nil {
+		return "", ""
+	}
+
+	ctrs := make([]*kube.ContainerInfo, 0, len(pod.Containers))
+	if m := pod.Main(); m != nil {
+		ctrs = append(ctrs, m)
+	}
+	for i := range pod.Containers {
+		if !pod.Containers[i].Main {
+			ctrs = append(ctrs, &pod.Containers[i])
+		}
+	}
+
+	for _, ctr := range ctrs {
+		if ctr.Waiting != "" && !startingReasons[ctr.Waiting] {
+			return ctr.Waiting, containerReason(ctr, ctr.Waiting)
+		}
+	}
+	if m := pod.Main(); m != nil && !m.Ready && m.Restarts > 0 && pod.Phase == "Running" {
+		return "not ready", containerReason(m, "not ready")
+	}
+	return "", ""
+}
+
+// containerReason describes a container problem with its restart history:
+// "deckhouse: CrashLoopBackOff, 12 restarts, last exit: Error (code 1)".
+func containerReason(ctr *kube.ContainerInfo, problem string) string {
+	reason := ctr.Name + ": " + problem
+	if ctr.Restarts > 0 {
+		reason += fmt.Sprintf(", %d restarts", ctr.Restarts)
+	}
+	if t := ctr.LastTermination; t != nil {
+		reason += fmt.Sprintf(", last exit: %s (code %d)", t.Reason, t.ExitCode)
+	}
+	return reason
+}
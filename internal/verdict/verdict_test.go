@@ -0,0 +1,310 @@
+package verdict
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glitchy-sheep/deckhouse-status/internal/github"
+	"github.com/glitchy-sheep/deckhouse-status/internal/kube"
+	"github.com/glitchy-sheep/deckhouse-status/internal/registry"
+)
+
+var (
+	buildDone  = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	deployed   = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	headSHA    = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	regMatch   = &registry.Result{TagExists: true, Digest: "sha256:new", DigestMatch: true}
+	regNewer   = &registry.Result{TagExists: true, Digest: "sha256:new"}
+	regGone    = &registry.Result{}
+	regFailed  = &registry.Result{Err: errors.New("401 Unauthorized")}
+	relLatest  = &github.ReleaseInfo{Tag: "v1.66.3", LatestPatch: "v1.66.3"}
+	relPatched = &github.ReleaseInfo{Tag: "v1.66.3", LatestPatch: "v1.66.4"}
+)
+
+// cluster is a single running replica created at podCreated.
+func cluster(podCreated time.Time) *kube.ClusterInfo {
+	return &kube.ClusterInfo{
+		PodName:    "deckhouse-1",
+		PodCreated: podCreated,
+		Pods: []kube.PodInfo{{
+			Name: "deckhouse-1", Phase: "Running", Created: podCreated, Ready: true,
+			Containers: []kube.ContainerInfo{{Name: "deckhouse", Main: true, Digest: "sha256:new", Ready: true}},
+		}},
+	}
+}
+
+// mixed adds a second, older replica running another image.
+func mixed() *kube.ClusterInfo {
+	c := cluster(buildDone.Add(time.Hour))
+	c.Pods = append(c.Pods, kube.PodInfo{
+		Name: "deckhouse-0", Phase: "Running", Created: buildDone.Add(-time.Hour), Ready: true,
+		Containers: []kube.ContainerInfo{{Name: "deckhouse", Main: true, Digest: "sha256:old", Ready: true}},
+	})
+	return c
+}
+
+func withPod(c *kube.ClusterInfo, pod kube.PodInfo) *kube.ClusterInfo {
+	c.Pods = append(c.Pods, pod)
+	return c
+}
+
+func head(status, conclusion string) *github.HeadBuild {
+	h := &github.HeadBuild{HeadSHA: headSHA, BuildCheckName: "Build FE", BuildStatus: status, BuildConclusion: conclusion}
+	if conclusion != "" {
+		h.BuildCompletedAt = buildDone
+	}
+	return h
+}
+
+func TestEvaluate(t *testing.T) {
+	after, before := cluster(buildDone.Add(time.Hour)), cluster(buildDone.Add(-time.Hour))
+
+	tests := []struct {
+		name       string
+		in         Input
+		wantKind   Kind
+		wantSource Source
+		wantAction Action
+		wantReason string // substring
+	}{
+		// Precedence.
+		{
+			name:     "mixed rollout wins over a running build and a registry match",
+			in:       Input{Cluster: mixed(), Head: head("in_progress", ""), Registry: regMatch},
+			wantKind: MixedRollout, wantSource: SourceCluster, wantAction: ActionRollout,
+			wantReason: "1 of 2 replicas run a different image than the newest replica deckhouse-1",
+		},
+		{
+			name:     "replicas that have not pulled their image are not mixed",
+			in:       Input{Cluster: withPod(cluster(buildDone.Add(time.Hour)), kube.PodInfo{Name: "deckhouse-2", Created: buildDone.Add(2 * time.Hour), Containers: []kube.ContainerInfo{{Name: "deckhouse", Main: true}}}), Registry: regMatch},
+			wantKind: UpToDate, wantSource: SourceRegistry,
+			wantReason: "digest matches registry",
+		},
+		{
+			name:     "running build wins over a registry match",
+			in:       Input{Cluster: after, Head: head("in_progress", ""), Registry: regMatch},
+			wantKind: Building, wantSource: SourceBuild, wantReason: "Build FE is running",
+		},
+		{
+			name:     "queued build wins over a registry match",
+			in:       Input{Cluster: after, Head: head("queued", ""), Registry: regMatch},
+			wantKind: Building, wantSource: SourceBuild,
+		},
+		{
+			name:     "digest match wins over the build",
+			in:       Input{Cluster: before, Head: head("completed", "success"), Registry: regMatch},
+			wantKind: UpToDate, wantSource: SourceRegistry, wantReason: "digest matches registry",
+		},
+		{
+			name:     "newer patch release wins over a digest match",
+			in:       Input{Cluster: after, Release: relPatched, Registry: regMatch},
+			wantKind: Outdated, wantSource: SourceRelease, wantAction: ActionUpgrade,
+			wantReason: "latest patch is v1.66.4",
+		},
+		{
+			name:     "digest match of the latest patch release",
+			in:       Input{Cluster: after, Release: relLatest, Registry: regMatch},
+			wantKind: UpToDate, wantSource: SourceRegistry, wantReason: "digest matches registry",
+		},
+		{
+			name:     "digest mismatch wins over the build",
+			in:       Input{Cluster: after, Head: head("completed", "success"), Registry: regNewer},
+			wantKind: Outdated, wantSource: SourceRegistry, wantAction: ActionRestart,
+			wantReason: "registry has newer image for tag",
+		},
+		{
+			name:     "digest mismatch wins over a matching commit",
+			in:       Input{Cluster: after, Head: head("completed", "success"), DeployedSHA: headSHA, Registry: regNewer},
+			wantKind: Outdated, wantSource: SourceRegistry, wantAction: ActionRestart,
+			wantReason: "registry has newer image for tag",
+		},
+
+		// Registry tag gone: commit, then build.
+		{
+			name:     "deployed commit is the head",
+			in:       Input{Cluster: before, Head: head("completed", "success"), DeployedSHA: headSHA, Registry: regGone, HeadName: "PR head"},
+			wantKind: UpToDate, wantSource: SourceCommit, wantReason: "deployed commit is PR head",
+		},
+		{
+			name:     "head commit not built yet",
+			in:       Input{Cluster: before, Head: head("", ""), DeployedSHA: deployed, Registry: regGone},
+			wantKind: WaitingForCI, wantSource: SourceBuild, wantReason: "Build FE not started yet",
+		},
+		{
+			name:     "head commit failed to build",
+			in:       Input{Cluster: before, Head: head("completed", "failure"), DeployedSHA: deployed, Registry: regGone},
+			wantKind: BuildFailed, wantSource: SourceBuild, wantAction: ActionCheckBuild,
+		},
+		{
+			name:     "deployed commit behind the head",
+			in:       Input{Cluster: before, Head: head("completed", "success"), DeployedSHA: deployed, Compare: &github.Comparison{AheadBy: 3}},
+			wantKind: Outdated, wantSource: SourceCommit, wantAction: ActionRestart,
+			wantReason: "deployed aaaaaaaaaaaa is 3 commits behind head",
+		},
+		{
+			name:     "deployed commit differs without a comparison",
+			in:       Input{Cluster: before, Head: head("completed", "success"), DeployedSHA: deployed},
+			wantKind: Outdated, wantSource: SourceCommit, wantAction: ActionRestart,
+			wantReason: "deployed aaaaaaaaaaaa, head bbbbbbbbbbbb",
+		},
+		{
+			name:     "pod created after the build",
+			in:       Input{Cluster: after, Head: head("completed", "success"), Registry: regGone},
+			wantKind: UpToDate, wantSource: SourceBuild, wantReason: "pod created after Build FE: 13:00 > 12:00",
+		},
+		{
+			name:     "build completed after the pod",
+			in:       Input{Cluster: before, Head: head("completed", "success"), Registry: regGone},
+			wantKind: Outdated, wantSource: SourceBuild, wantAction: ActionRestart,
+			wantReason: "Build FE completed after pod: 12:00 > 11:00",
+		},
+		{
+			name:     "build failed",
+			in:       Input{Cluster: before, Head: head("completed", "failure")},
+			wantKind: BuildFailed, wantSource: SourceBuild, wantAction: ActionCheckBuild,
+			wantReason: "Build FE failed on last commit",
+		},
+		{
+			name:     "build cancelled",
+			in:       Input{Cluster: before, Head: head("completed", "cancelled")},
+			wantKind: Unknown, wantSource: SourceBuild, wantReason: "Build FE status: completed",
+		},
+		{
+			name:     "no build for the head",
+			in:       Input{Cluster: before, Head: head("", "")},
+			wantKind: WaitingForCI, wantSource: SourceBuild, wantReason: "Build FE not started yet",
+		},
+
+		// Neither registry nor head.
+		{
+			name:     "release without registry data",
+			in:       Input{Cluster: after, Release: relLatest, Registry: regFailed},
+			wantKind: UpToDate, wantSource: SourceRelease, wantReason: "v1.66.3 is the latest patch",
+		},
+		{
+			name:     "unreadable index is not a newer image",
+			in:       Input{Cluster: after, Registry: &registry.Result{TagExists: true, Digest: "sha256:index", Err: errors.New("read image index: HTTP 500")}},
+			wantKind: Unknown, wantSource: SourceRegistry, wantReason: "registry: read image index",
+		},
+		{
+			name:     "registry error",
+			in:       Input{Cluster: after, Registry: regFailed},
+			wantKind: Unknown, wantSource: SourceRegistry, wantReason: "registry: 401 Unauthorized",
+		},
+		{
+			name:     "nothing known",
+			in:       Input{Cluster: after},
+			wantKind: Unknown, wantSource: SourceNone, wantReason: "no registry tag, no build info",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Evaluate(tt.in)
+			if v.Kind != tt.wantKind || v.Source != tt.wantSource || v.Action != tt.wantAction {
+				t.Errorf("Evaluate = %s/%s/%q (%q), want %s/%s/%q", v.Kind, v.Source, v.Action, v.Reason, tt.wantKind, tt.wantSource, tt.wantAction)
+			}
+			if !strings.Contains(v.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want %q", v.Reason, tt.wantReason)
+			}
+			if v.Health != "" {
+				t.Errorf("Health = %q, want healthy", v.Health)
+			}
+		})
+	}
+}
+
+func TestPodHealth(t *testing.T) {
+	sidecar := kube.ContainerInfo{Name: "kube-rbac-proxy", Ready: true}
+	main := kube.ContainerInfo{Name: "deckhouse", Main: true, Digest: "sha256:new", Ready: true}
+	crashing := main
+	crashing.Ready, crashing.Waiting, crashing.Restarts = false, "CrashLoopBackOff", 12
+	crashing.LastTermination = &kube.Termination{Reason: "Error", ExitCode: 1}
+
+	tests := []struct {
+		name       string
+		phase      string
+		containers []kube.ContainerInfo
+		wantHealth string
+		wantReason string
+	}{
+		{
+			name:       "running",
+			containers: []kube.ContainerInfo{sidecar, main},
+		},
+		{
+			name:       "deckhouse crashlooping",
+			containers: []kube.ContainerInfo{sidecar, crashing},
+			wantHealth: "CrashLoopBackOff",
+			wantReason: "deckhouse: CrashLoopBackOff, 12 restarts, last exit: Error (code 1)",
+		},
+		{
+			name: "deckhouse is reported before a listed-first sidecar",
+			containers: []kube.ContainerInfo{
+				{Name: "kube-rbac-proxy", Waiting: "ImagePullBackOff"},
+				crashing,
+			},
+			wantHealth: "CrashLoopBackOff",
+			wantReason: "deckhouse: CrashLoopBackOff",
+		},
+		{
+			name:       "sidecar waiting",
+			containers: []kube.ContainerInfo{{Name: "kube-rbac-proxy", Waiting: "ImagePullBackOff"}, main},
+			wantHealth: "ImagePullBackOff",
+			wantReason: "kube-rbac-proxy: ImagePullBackOff",
+		},
+		{
+			name:       "containers being started",
+			phase:      "Pending",
+			containers: []kube.ContainerInfo{{Name: "kube-rbac-proxy", Waiting: "PodInitializing"}, {Name: "deckhouse", Main: true, Waiting: "ContainerCreating"}},
+		},
+		{
+			name:       "restarted and not ready",
+			containers: []kube.ContainerInfo{sidecar, {Name: "deckhouse", Main: true, Restarts: 2}},
+			wantHealth: "not ready",
+			wantReason: "deckhouse: not ready, 2 restarts",
+		},
+		{
+			name:       "not ready yet after the start",
+			containers: []kube.ContainerInfo{sidecar, {Name: "deckhouse", Main: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cluster(buildDone)
+			c.Pods[0].Containers = tt.containers
+			if tt.phase != "" {
+				c.Pods[0].Phase = tt.phase
+			}
+			health, reason := podHealth(c)
+			if health != tt.wantHealth || !strings.HasPrefix(reason, tt.wantReason) || (tt.wantReason == "") != (reason == "") {
+				t.Errorf("podHealth = %q, %q; want %q, %q", health, reason, tt.wantHealth, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("no primary pod", func(t *testing.T) {
+		if health, _ := podHealth(&kube.ClusterInfo{PodName: "deckhouse-1"}); health != "" {
+			t.Errorf("podHealth = %q, want healthy", health)
+		}
+		if health, _ := podHealth(nil); health != "" {
+			t.Errorf("podHealth(nil) = %q, want healthy", health)
+		}
+	})
+}
+
+func TestEvaluateHealthAction(t *testing.T) {
+	c := cluster(buildDone)
+	c.Pods[0].Containers[0].Waiting = "CrashLoopBackOff"
+
+	// The health problem suggests its own action only when the image needs none.
+	if v := Evaluate(Input{Cluster: c, Registry: regMatch}); v.Kind != UpToDate || v.Health != "CrashLoopBackOff" || v.Action != ActionCheckPod {
+		t.Errorf("up to date: %s, health %q, action %q", v.Kind, v.Health, v.Action)
+	}
+	if v := Evaluate(Input{Cluster: c, Registry: regNewer}); v.Kind != Outdated || v.Health != "CrashLoopBackOff" || v.Action != ActionRestart {
+		t.Errorf("outdated: %s, health %q, action %q", v.Kind, v.Health, v.Action)
+	}
+}